@@ -24,11 +24,20 @@ import (
 type Binding struct {
 	pkg string
 	fps []*Failpoint
+	// registry is the variable holding the registry of the failpoints,
+	// or empty for the default registry
+	registry string
 }
 
 // NewBinding creates a new Binding for the given package and failpoints.
 func NewBinding(pkg string, fps []*Failpoint) *Binding {
-	return &Binding{pkg, fps}
+	return &Binding{pkg: pkg, fps: fps}
+}
+
+// SetRegistry registers the failpoints in the *runtime.Registry held by the
+// named package-level variable of the package, instead of the default registry.
+func (b *Binding) SetRegistry(registry string) {
+	b.registry = registry
 }
 
 // Write writes the fp.fail.go file for a package.
@@ -39,12 +48,18 @@ func (b *Binding) Write(dst io.Writer) error {
 	if _, err := fmt.Fprint(dst, hdr); err != nil {
 		return err
 	}
+	reg := "runtime"
+	if b.registry != "" {
+		reg = b.registry
+	}
 	for _, fp := range b.fps {
 		_, err := fmt.Fprintf(
 			dst,
-			"var %s *runtime.Failpoint = runtime.NewFailpoint(%q)\n",
+			"var %s *runtime.Failpoint = %s.NewTypedFailpoint(%q, %q)\n",
 			fp.Runtime(),
+			reg,
 			fp.Name(),
+			fp.varType,
 		)
 		if err != nil {
 			return err
@@ -25,7 +25,7 @@ import (
 func TestBindingWrite(t *testing.T) {
 	pkg := "testing"
 	comment := "// gofail: var Test int\n"
-	expected := "// GENERATED BY GOFAIL. DO NOT EDIT.\n\npackage testing\n\nimport \"go.etcd.io/gofail/runtime\"\n\nvar __fp_Test *runtime.Failpoint = runtime.NewFailpoint(\"Test\")\n"
+	expected := "// GENERATED BY GOFAIL. DO NOT EDIT.\n\npackage testing\n\nimport \"go.etcd.io/gofail/runtime\"\n\nvar __fp_Test *runtime.Failpoint = runtime.NewTypedFailpoint(\"Test\", \"int\")\n"
 
 	fp, err := newFailpoint(comment)
 	require.NoErrorf(t, err, "failed to create failpoint from comment: %s", comment)
@@ -38,3 +38,17 @@ func TestBindingWrite(t *testing.T) {
 	got := buf.String()
 	assert.Equal(t, expected, got)
 }
+
+func TestBindingWriteRegistry(t *testing.T) {
+	expected := "// GENERATED BY GOFAIL. DO NOT EDIT.\n\npackage testing\n\nimport \"go.etcd.io/gofail/runtime\"\n\nvar __fp_Test *runtime.Failpoint = fpRegistry.NewTypedFailpoint(\"Test\", \"[]string\")\n"
+
+	fp, err := newFailpoint("// gofail: var Test []string\n")
+	require.NoError(t, err)
+
+	b := NewBinding("testing", []*Failpoint{fp})
+	b.SetRegistry("fpRegistry")
+
+	var buf bytes.Buffer
+	require.NoError(t, b.Write(&buf))
+	assert.Equal(t, expected, buf.String())
+}
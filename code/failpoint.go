@@ -25,6 +25,8 @@ type Failpoint struct {
 	name    string
 	varType string
 	code    []string
+	// ctx is set if the failpoint is evaluated with the ctx variable in scope
+	ctx bool
 
 	// whitespace for padding
 	ws string
@@ -42,10 +44,15 @@ func newFailpoint(l string) (*Failpoint, error) {
 	pfx := pfxGofail
 	cmd := strings.SplitAfter(l, pfx)[1]
 	fields := strings.Fields(cmd)
+	// a trailing "ctx" evaluates the failpoint with the ctx variable in scope
+	ctx := len(fields) == 4 && fields[3] == "ctx"
+	if ctx {
+		fields = fields[:3]
+	}
 	if len(fields) != 3 || fields[0] != "var" {
 		return nil, fmt.Errorf("failpoint: malformed comment header %q", l)
 	}
-	return &Failpoint{name: fields[1], varType: fields[2], ws: strings.Split(l, "//")[0]}, nil
+	return &Failpoint{name: fields[1], varType: fields[2], ctx: ctx, ws: strings.Split(l, "//")[0]}, nil
 }
 
 // flush writes the failpoint code to a buffer
@@ -59,7 +66,11 @@ func (fp *Failpoint) flush(dst io.Writer) error {
 func (fp *Failpoint) hdr(varname string) string {
 	ev := errVarGoFail
 
-	hdr := fp.ws + "if v" + fp.name + fmt.Sprintf(", %s := ", ev) + fp.Runtime() + ".Acquire();" + fmt.Sprintf(" %s == nil { ", ev)
+	acquire := ".Acquire();"
+	if fp.ctx {
+		acquire = ".AcquireCtx(ctx);"
+	}
+	hdr := fp.ws + "if v" + fp.name + fmt.Sprintf(", %s := ", ev) + fp.Runtime() + acquire + fmt.Sprintf(" %s == nil { ", ev)
 
 	if fp.varType == "struct{}" {
 		// unused
@@ -113,12 +113,17 @@ func ToComments(wdst io.Writer, rsrc io.Reader) ([]*Failpoint, error) {
 			ws = strings.Split(l, "i")[0]
 			n := strings.Split(strings.Split(l, "__fp_")[1], ".")[0]
 			t := strings.Split(strings.Split(l, ".(")[1], ")")[0]
-			dst.WriteString(ws + pfx + " var " + n + " " + t + "\n")
+			isCtx := strings.Contains(l, ".AcquireCtx(ctx);")
+			if isCtx {
+				dst.WriteString(ws + pfx + " var " + n + " " + t + " ctx\n")
+			} else {
+				dst.WriteString(ws + pfx + " var " + n + " " + t + "\n")
+			}
 			if !strings.Contains(l, "; goto __nomock") {
 				// not single liner
 				unmatchedBraces = 1
 			}
-			fps = append(fps, &Failpoint{name: n, varType: t})
+			fps = append(fps, &Failpoint{name: n, varType: t, ctx: isCtx})
 			continue
 		}
 
@@ -76,6 +76,16 @@ var examples = []struct {
 		"func f() {\n\tif vNoTypeTest, __fpErr := __fp_NoTypeTest.Acquire(); __fpErr == nil { _, __fpTypeOK := vNoTypeTest.(struct{}); if !__fpTypeOK { goto __badTypeNoTypeTest} \n\t\t fmt.Println(`hi`)\n\t\t fmt.Println(`bye`); goto __nomockNoTypeTest; __badTypeNoTypeTest: __fp_NoTypeTest.BadType(vNoTypeTest, \"struct{}\"); __nomockNoTypeTest: };\n}\n",
 		1,
 	},
+	{
+		"func f(ctx context.Context) {\n\t// gofail: var CtxTest int ctx\n\t// fmt.Println(CtxTest)\n}\n",
+		"func f(ctx context.Context) {\n\tif vCtxTest, __fpErr := __fp_CtxTest.AcquireCtx(ctx); __fpErr == nil { CtxTest, __fpTypeOK := vCtxTest.(int); if !__fpTypeOK { goto __badTypeCtxTest} \n\t\t fmt.Println(CtxTest); goto __nomockCtxTest; __badTypeCtxTest: __fp_CtxTest.BadType(vCtxTest, \"int\"); __nomockCtxTest: };\n}\n",
+		1,
+	},
+	{
+		"func f(ctx context.Context) {\n\t// gofail: var CtxOneLineTest struct{} ctx\n}\n",
+		"func f(ctx context.Context) {\n\tif vCtxOneLineTest, __fpErr := __fp_CtxOneLineTest.AcquireCtx(ctx); __fpErr == nil { _, __fpTypeOK := vCtxOneLineTest.(struct{}); if !__fpTypeOK { goto __badTypeCtxOneLineTest} ; goto __nomockCtxOneLineTest; __badTypeCtxOneLineTest: __fp_CtxOneLineTest.BadType(vCtxOneLineTest, \"struct{}\"); __nomockCtxOneLineTest: };\n}\n",
+		1,
+	},
 	{
 		`
 func f() {
@@ -124,3 +134,15 @@ func TestToComment(t *testing.T) {
 		require.Equalf(t, len(fps), ex.wfps, "%d: got %d failpoints but expected %d", i, len(fps), ex.wfps)
 	}
 }
+
+func TestMalformedHeader(t *testing.T) {
+	for _, l := range []string{
+		"// gofail: var Test",
+		"// gofail: var Test int extra",
+		"// gofail: var Test int ctx extra",
+		"// gofail: val Test int ctx",
+	} {
+		_, err := ToFailpoints(&bytes.Buffer{}, strings.NewReader("func f() {\n\t"+l+"\n}\n"))
+		require.Errorf(t, err, "expected %q to be rejected", l)
+	}
+}
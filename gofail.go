@@ -17,6 +17,7 @@ package main
 
 import (
 	"fmt"
+	"go/token"
 	"io"
 	"os"
 	"path"
@@ -35,8 +36,9 @@ var (
 )
 
 var usageLine = `Usage:
-gofail enable [list of files or directories]
-    Enable the failpoints
+gofail enable [--registry <variable>] [list of files or directories]
+    Enable the failpoints, registering them in the *runtime.Registry held
+    by the given package-level variable instead of the default registry
 
 gofail disable [list of files or directories]
     Disable the checkpoints
@@ -133,7 +135,7 @@ func paths2files(paths []string) (files []string) {
 	return files
 }
 
-func writeBinding(file string, fps []*code.Failpoint) {
+func writeBinding(file string, fps []*code.Failpoint, registry string) {
 	if len(fps) == 0 {
 		return
 	}
@@ -146,7 +148,9 @@ func writeBinding(file string, fps []*code.Failpoint) {
 	// XXX: support "package main"
 	pkgAbsDir := path.Dir(file)
 	pkg := path.Base(pkgAbsDir)
-	code.NewBinding(pkg, fps).Write(out)
+	b := code.NewBinding(pkg, fps)
+	b.SetRegistry(registry)
+	b.Write(out)
 	out.Close()
 }
 
@@ -158,10 +162,13 @@ func main() {
 
 	var xfrm xfrmFunc
 	enable := false
+	args := os.Args[2:]
+	registry := ""
 	switch os.Args[1] {
 	case "enable":
 		xfrm = code.ToFailpoints
 		enable = true
+		registry, args = registryFlag(args)
 	case "disable":
 		xfrm = code.ToComments
 	case "--version":
@@ -171,7 +178,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	files := paths2files(os.Args[2:])
+	files := paths2files(args)
 	fps := [][]*code.Failpoint{}
 	for _, path := range files {
 		curfps, err := xfrmFile(xfrm, path)
@@ -185,7 +192,7 @@ func main() {
 	if enable {
 		// build runtime bindings <FILE>.fail.go
 		for i := range files {
-			writeBinding(files[i], fps[i])
+			writeBinding(files[i], fps[i], registry)
 		}
 	} else {
 		// remove all runtime bindings
@@ -196,6 +203,26 @@ func main() {
 	}
 }
 
+// registryFlag extracts the --registry flag from the enable arguments.
+func registryFlag(args []string) (string, []string) {
+	if len(args) == 0 || !strings.HasPrefix(args[0], "--registry") {
+		return "", args
+	}
+	registry, ok := strings.CutPrefix(args[0], "--registry=")
+	if !ok {
+		if args[0] != "--registry" || len(args) < 2 {
+			fmt.Println(usageLine)
+			os.Exit(1)
+		}
+		registry, args = args[1], args[1:]
+	}
+	if !token.IsIdentifier(registry) {
+		fmt.Printf("invalid registry variable %q\n", registry)
+		os.Exit(1)
+	}
+	return registry, args[1:]
+}
+
 func showVersion() {
 	fmt.Println("Git SHA: ", GitSHA)
 	fmt.Println("Go Version: ", runtime.Version())
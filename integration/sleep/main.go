@@ -36,14 +36,16 @@ func main() {
 	}
 
 	{
-		// expectation: this part of the code will take about 6s to execute only,
-		// because all go routines will be executing concurrently, with both the sleep
-		// from failpoint and the original sleep actions
+		// expectation: this part of the code will take about 3s to execute only,
+		// because all go routines will be executing concurrently, and the sleep
+		// from failpoint is interrupted as soon as the failpoint is disabled, leaving
+		// only the original sleep actions
 		//
 		// The gofail implementation up till commit 93c579a86c46 is executing the
 		// program sequentially, due to the failpoint action execution and enable/disable
 		// flows are under the same locking mechanism, only one of the actions can make
-		// progress at a given moment
+		// progress at a given moment. Later on, the failpoint sleep kept running after
+		// disabling the failpoint, taking about 6s.
 		log.Println("Stage 2: Run 3 workers under failpoint logic")
 
 		start := time.Now()
@@ -64,7 +66,8 @@ func main() {
 		go failpoints.Worker3(&wg)
 		time.Sleep(10 * time.Millisecond)
 
-		// the failpoint can be disabled during failpoint execution
+		// the failpoint can be disabled during failpoint execution, which
+		// interrupts the ongoing sleep
 		gofail.Disable("worker1Failpoint")
 		gofail.Disable("worker2Failpoint")
 		gofail.Disable("worker3Failpoint")
@@ -72,7 +75,7 @@ func main() {
 		wg.Wait()
 
 		elapsed := time.Since(start)
-		if elapsed > (6*time.Second + 100*time.Millisecond) {
+		if elapsed > (3*time.Second + 100*time.Millisecond) {
 			log.Fatalln("invalid execution time", elapsed)
 		}
 
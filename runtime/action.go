@@ -0,0 +1,123 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ActionContext describes the execution of a custom action.
+type ActionContext struct {
+	// Name is the name of the failpoint.
+	Name string
+	// Arg is the parsed argument of the action, or struct{}{} if none was given.
+	Arg any
+	// Count is the execution count of the failpoint terms, including this execution.
+	Count int
+	// Term is the canonical description of the term being executed.
+	Term string
+	// Context is the context the failpoint is evaluated with, which is
+	// context.Background() unless evaluated by AcquireCtx. Blocking actions
+	// should return once it is done.
+	Context context.Context
+}
+
+// ActionFunc is a custom action. The returned value is what the failpoint
+// evaluates to; a nil value leaves the failpoint untriggered. An error is
+// reported and also leaves the failpoint untriggered.
+type ActionFunc func(ctx ActionContext) (any, error)
+
+var (
+	// customActs holds the actions registered by RegisterAction.
+	customActs = make(map[string]actFunc)
+	// actMu protects customActs
+	actMu sync.RWMutex
+)
+
+// reservedNames are identifiers with a meaning of their own in terms.
+var reservedNames = map[string]bool{
+	"after": true,
+	"for":   true,
+	"true":  true,
+	"false": true,
+}
+
+// RegisterAction registers a custom action, which can be used in terms
+// like the built-in actions, e.g. "2*name" or "name(arg)". Terms using the
+// action can only be parsed after it is registered. It panics if the name
+// is not a valid identifier or is already taken.
+func RegisterAction(name string, fn ActionFunc) {
+	if !isIdent(name) || reservedNames[name] {
+		panic(fmt.Sprintf("failpoint action name %q is invalid.", name))
+	}
+
+	actMu.Lock()
+	defer actMu.Unlock()
+	if _, ok := actMap[name]; ok {
+		panic(fmt.Sprintf("failpoint action %s is already registered.", name))
+	}
+	if _, ok := customActs[name]; ok {
+		panic(fmt.Sprintf("failpoint action %s is already registered.", name))
+	}
+	customActs[name] = func(ctx context.Context, t *term, val interface{}, count int) interface{} {
+		v, err := fn(ActionContext{Name: t.parent.fpath, Arg: val, Count: count, Term: t.desc, Context: ctx})
+		if err != nil {
+			getLogger().Error("failpoint: action failed", "failpoint", t.parent.fpath, "action", name, "error", err)
+			return nil
+		}
+		return v
+	}
+}
+
+// Actions returns the sorted names of all built-in and registered actions.
+func Actions() []string {
+	actMu.RLock()
+	defer actMu.RUnlock()
+	ret := make([]string, 0, len(actMap)+len(customActs))
+	for name := range actMap {
+		ret = append(ret, name)
+	}
+	for name := range customActs {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func lookupAction(name string) (actFunc, bool) {
+	if act, ok := actMap[name]; ok {
+		return act, true
+	}
+	actMu.RLock()
+	defer actMu.RUnlock()
+	act, ok := customActs[name]
+	return act, ok
+}
+
+// isIdent reports whether the name lexes as a single action identifier.
+func isIdent(name string) bool {
+	if len(name) == 0 || !isLetter(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isLetter(name[i]) && !isDigit(name[i]) {
+			return false
+		}
+	}
+	return true
+}
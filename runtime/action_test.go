@@ -0,0 +1,95 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterAction(t *testing.T) {
+	defer clearCustomActions()
+
+	var got []ActionContext
+	RegisterAction("drop", func(ctx ActionContext) (any, error) {
+		got = append(got, ctx)
+		return ctx.Arg, nil
+	})
+	RegisterAction("broken", func(ActionContext) (any, error) {
+		return "unused", errors.New("broken action")
+	})
+
+	ter, err := newTerms("test", `1*broken->drop("msg") -> drop`)
+	require.NoError(t, err)
+
+	assert.Nil(t, ter.eval())
+	assert.Equal(t, "msg", ter.eval())
+	assert.Equal(t, "msg", ter.eval())
+	assert.Equal(t, []ActionContext{
+		{Name: "test", Arg: "msg", Count: 2, Term: `drop("msg")`, Context: context.Background()},
+		{Name: "test", Arg: "msg", Count: 3, Term: `drop("msg")`, Context: context.Background()},
+	}, got)
+
+	assert.Contains(t, Actions(), "drop")
+	assert.Contains(t, Actions(), "broken")
+	assert.Contains(t, Actions(), "return")
+}
+
+func TestRegisterActionInvalid(t *testing.T) {
+	defer clearCustomActions()
+
+	noop := func(ActionContext) (any, error) { return nil, nil }
+	RegisterAction("noop", noop)
+	for _, name := range []string{"", "return", "noop", "after", "true", "io.EOF", "1st", "with space"} {
+		assert.Panicsf(t, func() { RegisterAction(name, noop) }, "expected registering %q to panic", name)
+	}
+
+	_, err := newTerms("test", "unknown")
+	require.ErrorIs(t, err, ErrBadParse)
+}
+
+func TestHTTPActions(t *testing.T) {
+	defer clearCustomActions()
+
+	RegisterAction("drop", func(ActionContext) (any, error) { return nil, nil })
+
+	rec := httptest.NewRecorder()
+	defaultRegistry.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/-/actions", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "alloc\nbreak\nburn\ndrop\nerror\nexit\nfds\ngoroutineprofile\nheapprofile\nkill\nlog\noff\npanic\npause\nprint\nreturn\nsignal\nsleep\nstack\nyield\n", rec.Body.String())
+
+	// the endpoint is not shadowed by a failpoint named after it
+	reg := NewRegistry()
+	reg.NewFailpoint("actions")
+	require.NoError(t, reg.Enable("actions", "return(1)"))
+	rec = httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/actions", nil))
+	assert.Equal(t, "return(1)\n", rec.Body.String())
+	rec = httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/-/actions", nil))
+	assert.Contains(t, rec.Body.String(), "\nreturn\n")
+}
+
+func clearCustomActions() {
+	actMu.Lock()
+	defer actMu.Unlock()
+	customActs = make(map[string]actFunc)
+}
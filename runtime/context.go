@@ -0,0 +1,66 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+)
+
+// ctxKey is the context key of the terms attached by WithFailpoint to the
+// failpoints of a registry.
+type ctxKey struct {
+	r *Registry
+}
+
+// WithFailpoint returns a copy of ctx carrying the given terms for the named
+// failpoint of the default registry, see Registry.WithFailpoint.
+func WithFailpoint(ctx context.Context, name, inTerms string) (context.Context, error) {
+	return defaultRegistry.WithFailpoint(ctx, name, inTerms)
+}
+
+// WithFailpoint returns a copy of ctx carrying the given terms for the named
+// failpoint. Evaluating the failpoint with AcquireCtx and the returned context,
+// or a context derived from it, executes these terms instead of the ones set by
+// Enable, so a single request can be faulted while the others behave normally.
+// The terms have their own execution counter, and are stopped once ctx is done,
+// releasing the goroutines blocked in their actions. On error, ctx is returned
+// unchanged.
+func (reg *Registry) WithFailpoint(ctx context.Context, name, inTerms string) (context.Context, error) {
+	fp := reg.lookup(name)
+	if fp == nil {
+		return ctx, ErrNoExist
+	}
+
+	t, err := reg.newTerms(fp, inTerms)
+	if err != nil {
+		return ctx, err
+	}
+	context.AfterFunc(ctx, t.stop)
+
+	key := ctxKey{reg}
+	parent, _ := ctx.Value(key).(map[string]*terms)
+	fpTerms := make(map[string]*terms, len(parent)+1)
+	for k, v := range parent {
+		fpTerms[k] = v
+	}
+	fpTerms[name] = t
+	return context.WithValue(ctx, key, fpTerms), nil
+}
+
+// ctxTerms returns the terms attached to ctx for the named failpoint, if any.
+func (reg *Registry) ctxTerms(ctx context.Context, name string) *terms {
+	fpTerms, _ := ctx.Value(ctxKey{reg}).(map[string]*terms)
+	return fpTerms[name]
+}
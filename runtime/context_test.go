@@ -0,0 +1,153 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithFailpoint(t *testing.T) {
+	defer clearGlobalVars()
+
+	fp := NewTypedFailpoint("ctxfp", "int")
+	other := NewTypedFailpoint("other", "string")
+
+	ctx, err := WithFailpoint(context.Background(), "ctxfp", "2*return(1)")
+	require.NoError(t, err)
+	ctx, err = WithFailpoint(ctx, "other", `return("x")`)
+	require.NoError(t, err)
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	_, err = fp.Acquire()
+	assert.ErrorIsf(t, err, ErrDisabled, "terms attached to a context should not fire without it")
+	_, err = fp.AcquireCtx(context.Background())
+	assert.ErrorIs(t, err, ErrDisabled)
+
+	require.NoError(t, Enable("ctxfp", "return(2)"))
+	v, err := fp.AcquireCtx(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+	v, err = fp.AcquireCtx(child)
+	require.NoError(t, err)
+	assert.Equalf(t, 1, v, "derived contexts should carry the terms")
+	_, err = fp.AcquireCtx(ctx)
+	assert.ErrorIsf(t, err, ErrDisabled, "context terms should replace the enabled ones")
+	v, err = fp.Acquire()
+	require.NoError(t, err)
+	assert.Equal(t, 2, v)
+
+	v, err = other.AcquireCtx(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "x", v)
+
+	for _, tt := range []struct {
+		name, terms string
+		werr        error
+	}{
+		{"missing", "return(1)", ErrNoExist},
+		{"ctxfp", "return(", ErrBadParse},
+		{"ctxfp", `return("1")`, ErrBadType},
+	} {
+		got, err := WithFailpoint(ctx, tt.name, tt.terms)
+		assert.ErrorIsf(t, err, tt.werr, "unexpected error for %s=%s", tt.name, tt.terms)
+		assert.Equalf(t, ctx, got, "the context should be returned unchanged for %s=%s", tt.name, tt.terms)
+	}
+}
+
+func TestAcquireCtxDone(t *testing.T) {
+	defer clearGlobalVars()
+
+	fp := NewFailpoint("blocking")
+	require.NoError(t, Enable("blocking", `sleep("10s")`))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := fp.AcquireCtx(ctx)
+	assert.ErrorIs(t, err, ErrDisabled)
+	assert.Lessf(t, time.Since(start), 5*time.Second, "sleep should return once the context is done")
+
+	// pausing terms attached to a context are released when it is done
+	ctx, cancel = context.WithCancel(context.Background())
+	ctx, err = WithFailpoint(ctx, "blocking", `pause&error("x")`)
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() {
+		_, err := fp.AcquireCtx(ctx)
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return defaultRegistry.ctxTerms(ctx, "blocking").pausedCount() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIsf(t, err, ErrDisabled, "the actions after a pause cut short should not run")
+	case <-time.After(5 * time.Second):
+		t.Fatal("pause should return once the context is done")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	defer clearGlobalVars()
+
+	fp := NewTypedFailpoint("handlerfp", "string")
+	NewFailpoint("other")
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, err := fp.AcquireCtx(r.Context())
+		if err != nil {
+			fmt.Fprint(w, "ok")
+			return
+		}
+		fmt.Fprint(w, v)
+	}))
+
+	serve := func(header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, h := range header {
+			req.Header.Add(Header, h)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve()
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+
+	rec = serve(`handlerfp=return("injected");other=sleep(10)`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "injected", rec.Body.String())
+	rec = serve(`other=off`, `handlerfp=return("second")`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equalf(t, "second", rec.Body.String(), "every header value should be applied")
+
+	_, err := fp.Acquire()
+	assert.ErrorIsf(t, err, ErrDisabled, "header terms should not be enabled globally")
+	assert.Equal(t, "ok", serve().Body.String())
+
+	for _, header := range []string{`handlerfp`, `handlerfp=return(`, `missing=return(1)`, `handlerfp=return(1)`} {
+		rec = serve(header)
+		assert.Equalf(t, http.StatusBadRequest, rec.Code, "expected header %q to be rejected", header)
+	}
+}
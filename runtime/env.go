@@ -0,0 +1,74 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !gofail_disabled
+
+package runtime
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// readEnv sets up the runtime from the GOFAIL_* environment variables. The
+// gofail_disabled build tag leaves them unread, so a production build cannot
+// be faulted through its environment.
+func readEnv() {
+	if s := os.Getenv("GOFAIL_SEED"); len(s) > 0 {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			getLogger().Error("fail to parse GOFAIL_SEED", "error", err)
+			os.Exit(1)
+		}
+		SetSeed(v)
+	}
+	if os.Getenv("GOFAIL_SEED") != "" || os.Getenv("GOFAIL_FAILPOINTS") != "" || os.Getenv("GOFAIL_HTTP") != "" {
+		// print the seed so a run can be replayed
+		logSeed()
+	}
+	if s := os.Getenv("GOFAIL_STRESS"); len(s) > 0 {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			getLogger().Error("fail to parse GOFAIL_STRESS", "error", err)
+			os.Exit(1)
+		}
+		stressSeed, stressEnabled = v, true
+		getLogger().Info("failpoint: stress mode enabled", "seed", v)
+	}
+	if s := os.Getenv("GOFAIL_FAILPOINTS"); len(s) > 0 {
+		fpMap, err := parseFailpoints(s)
+		if err != nil {
+			getLogger().Error("fail to parse failpoint", "error", err)
+			os.Exit(1)
+		}
+		defaultRegistry.envTerms = fpMap
+	}
+	if s := os.Getenv("GOFAIL_HTTP"); len(s) > 0 {
+		if err := serve(s); err != nil {
+			getLogger().Error("fail to serve failpoints", "error", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func serve(host string) error {
+	ln, err := net.Listen("tcp", host)
+	if err != nil {
+		return err
+	}
+	go http.Serve(ln, defaultRegistry)
+	return nil
+}
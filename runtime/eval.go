@@ -0,0 +1,74 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !gofail_disabled
+
+package runtime
+
+import "reflect"
+
+// New creates and registers a new failpoint with the given name, declared of
+// type T, for use with Eval in hand-written failpoints, e.g.
+//
+//	var fpLatency = runtime.New[time.Duration]("Latency")
+//
+// With the gofail_disabled build tag, it returns nil and registers nothing.
+func New[T any](name string) *Failpoint {
+	return NewTypedFailpoint(name, reflect.TypeFor[T]().String())
+}
+
+// Eval evaluates the failpoint, returning its value as a T and true if the
+// failpoint is triggered. Values of another type are converted to T when
+// possible, as for failpoints declared with a type, e.g.
+//
+//	if d, ok := runtime.Eval[time.Duration](fpLatency); ok {
+//		time.Sleep(d)
+//	}
+//
+// With the gofail_disabled build tag, it always returns false, so the
+// failpoint costs nothing in production builds.
+func Eval[T any](fp *Failpoint) (T, bool) {
+	var zero T
+	v, err := fp.Acquire()
+	if err != nil {
+		return zero, false
+	}
+	if tv, ok := v.(T); ok {
+		return tv, true
+	}
+	typ := reflect.TypeFor[T]()
+	if cv, ok := convertValue(v, typ); ok {
+		if tv, ok := cv.(T); ok {
+			return tv, true
+		}
+	}
+	fp.BadType(v, typ.String())
+	return zero, false
+}
+
+// Inject calls fn with the value of the named failpoint if it is triggered.
+// The failpoint is registered with type T in the default registry when it is
+// first reached, unless it is already registered, e.g. by New. Terms can be
+// set for it using GOFAIL_FAILPOINTS, or with Enable once it is registered.
+//
+// With the gofail_disabled build tag, it does nothing.
+func Inject[T any](name string, fn func(v T)) {
+	fp := defaultRegistry.lookup(name)
+	if fp == nil {
+		fp, _ = defaultRegistry.getOrRegister(name, reflect.TypeFor[T]().String())
+	}
+	if v, ok := Eval[T](fp); ok {
+		fn(v)
+	}
+}
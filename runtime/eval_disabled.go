@@ -0,0 +1,32 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build gofail_disabled
+
+package runtime
+
+// New returns nil, as failpoints are disabled by the gofail_disabled build
+// tag.
+func New[T any](name string) *Failpoint { return nil }
+
+// Eval returns false, as failpoints are disabled by the gofail_disabled build
+// tag.
+func Eval[T any](fp *Failpoint) (T, bool) {
+	var zero T
+	return zero, false
+}
+
+// Inject does nothing, as failpoints are disabled by the gofail_disabled
+// build tag.
+func Inject[T any](name string, fn func(v T)) {}
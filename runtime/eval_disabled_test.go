@@ -0,0 +1,59 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build gofail_disabled
+
+package runtime
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalDisabled(t *testing.T) {
+	fp := New[int]("disabled")
+	assert.Nil(t, fp)
+	assert.NotContains(t, List(), "disabled")
+
+	_, ok := Eval[int](fp)
+	assert.False(t, ok)
+	Inject("disabled", func(int) { t.Fatal("failpoints should be disabled") })
+	assert.NotContains(t, List(), "disabled")
+}
+
+func TestEnvDisabled(t *testing.T) {
+	if os.Getenv("GOFAIL_TEST_ENV") != "" {
+		// the child process, started with GOFAIL_* set
+		fp := NewFailpoint("envdisabled")
+		_, err := fp.Acquire()
+		require.ErrorIsf(t, err, ErrDisabled, "GOFAIL_FAILPOINTS should be ignored")
+		return
+	}
+
+	// an address in use makes serving GOFAIL_HTTP fail the process
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestEnvDisabled$")
+	cmd.Env = append(os.Environ(), "GOFAIL_TEST_ENV=1",
+		"GOFAIL_FAILPOINTS=envdisabled=return(1)", "GOFAIL_HTTP="+ln.Addr().String())
+	out, err := cmd.CombinedOutput()
+	require.NoErrorf(t, err, "GOFAIL_* should be ignored, output: %s", out)
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !gofail_disabled
+
+package runtime
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEval(t *testing.T) {
+	defer clearGlobalVars()
+
+	fp := New[time.Duration]("latency")
+	typ, err := Type("latency")
+	require.NoError(t, err)
+	assert.Equal(t, "time.Duration", typ)
+
+	_, ok := Eval[time.Duration](fp)
+	assert.Falsef(t, ok, "disabled failpoint should not trigger")
+
+	require.NoError(t, Enable("latency", "1*return(250ms)->1*return(1)->off"))
+	d, ok := Eval[time.Duration](fp)
+	require.True(t, ok)
+	assert.Equal(t, 250*time.Millisecond, d)
+	d, ok = Eval[time.Duration](fp)
+	require.True(t, ok)
+	assert.Equal(t, time.Duration(1), d)
+	_, ok = Eval[time.Duration](fp)
+	assert.False(t, ok)
+
+	require.ErrorIs(t, Enable("latency", `return("abc")`), ErrBadType)
+}
+
+func TestEvalUntyped(t *testing.T) {
+	defer clearGlobalVars()
+
+	fp := NewFailpoint("untyped")
+	require.NoError(t, Enable("untyped", "1*return(1)->return(1.5)"))
+	v, ok := Eval[int64](fp)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), v)
+	_, ok = Eval[int64](fp)
+	assert.Falsef(t, ok, "values which do not convert should not trigger")
+
+	// nil has no value without a declared type
+	require.ErrorIs(t, Enable("untyped", "return(nil)"), ErrBadType)
+	ter, err := newTerms("untyped", "return(nil)")
+	require.NoError(t, err)
+	fp.SetTerm(ter)
+	_, ok = Eval[any](fp)
+	assert.Falsef(t, ok, "nil should not trigger a failpoint of unknown type")
+}
+
+func TestInject(t *testing.T) {
+	defaultRegistry.envTerms = map[string]string{"inject": `2*return([]string{"a","b"})`}
+	defer clearGlobalVars()
+
+	var got [][]string
+	for i := 0; i < 3; i++ {
+		Inject("inject", func(v []string) { got = append(got, v) })
+	}
+	assert.Equal(t, [][]string{{"a", "b"}, {"a", "b"}}, got)
+
+	typ, err := Type("inject")
+	require.NoError(t, err)
+	assert.Equal(t, "[]string", typ)
+
+	// concurrent first uses register the failpoint once
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Inject("concurrent", func(struct{}) {})
+		}()
+	}
+	wg.Wait()
+	assert.Contains(t, List(), "concurrent")
+}
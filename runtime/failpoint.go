@@ -16,56 +16,111 @@
 package runtime
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"sync"
 )
 
 // Failpoint represents a runtime failpoint that can be enabled, disabled, and evaluated.
 type Failpoint struct {
-	t   *terms
-	mux sync.RWMutex
+	name string
+	t    *terms
+	mux  sync.RWMutex
+
+	// reg is the registry of the failpoint
+	reg *Registry
+
+	// stress perturbs the scheduling when the failpoint has no terms, if not nil
+	stress *stressor
+	// varType is the declared type of the failpoint values, if any
+	varType string
+	// typ is the declared type of the failpoint values, if made of known types
+	typ reflect.Type
 }
 
-// NewFailpoint creates and registers a new failpoint with the given name.
+// NewFailpoint creates and registers a new failpoint with the given name
+// in the default registry. It panics if the name is already registered.
 func NewFailpoint(name string) *Failpoint {
-	return register(name)
+	return defaultRegistry.NewFailpoint(name)
+}
+
+// NewTypedFailpoint creates and registers a new failpoint with the given name
+// and declared type in the default registry, such as "int64" or "[]string".
+// Values of the failpoint are converted to the declared type when it is made
+// of known types, e.g. 1 to int64(1) or "10ms" to 10*time.Millisecond, and
+// terms evaluating to values which do not convert are rejected by Enable.
+func NewTypedFailpoint(name, varType string) *Failpoint {
+	return defaultRegistry.NewTypedFailpoint(name, varType)
 }
 
 // Acquire gets evalutes the failpoint terms; if the failpoint
 // is active, it will return a value. Otherwise, returns a non-nil error.
 //
-// Notice that during the exection of Acquire(), the failpoint can be disabled,
-// but the already in-flight execution won't be terminated
+// Notice that during the exection of Acquire(), the failpoint can be disabled
+// or set to new terms. The already in-flight execution won't be terminated, but
+// blocking actions such as sleep and pause return immediately.
 func (fp *Failpoint) Acquire() (interface{}, error) {
-	fp.mux.RLock()
-	// terms are locked during execution, so deepcopy is not required as no change can be made during execution
-	cachedT := fp.t
-	fp.mux.RUnlock()
+	return fp.AcquireCtx(context.Background())
+}
 
+// AcquireCtx is like Acquire, for calls carrying a context. If terms were
+// attached to ctx for the failpoint by WithFailpoint, they are evaluated
+// instead of the failpoint terms. Blocking actions such as sleep and pause
+// return once ctx is done.
+func (fp *Failpoint) AcquireCtx(ctx context.Context) (interface{}, error) {
+	cachedT := fp.reg.ctxTerms(ctx, fp.name)
 	if cachedT == nil {
+		fp.mux.RLock()
+		// terms lock their mutable state during evaluation, so deepcopy is not required
+		cachedT = fp.t
+		fp.mux.RUnlock()
+	}
+
+	if cachedT == nil {
+		if fp.stress != nil {
+			fp.stress.perturb()
+		}
 		return nil, ErrDisabled
 	}
-	result := cachedT.eval()
+	result := cachedT.evalCtx(ctx)
 	if result == nil {
 		return nil, ErrDisabled
 	}
+	if fp.typ != nil {
+		// leave unconvertible values to BadType
+		result, _ = convertValue(result, fp.typ)
+	}
+	if _, ok := result.(nilValue); ok {
+		// nil without a type to convert it to, rejected by Enable
+		return nil, ErrDisabled
+	}
 	return result, nil
 }
 
+// Type returns the declared type of the failpoint, which is empty if unknown.
+func (fp *Failpoint) Type() string { return fp.varType }
+
 // BadType is called when the failpoint evaluates to the wrong type.
 func (fp *Failpoint) BadType(v interface{}, t string) {
-	fmt.Printf("failpoint: %q got value %v of type \"%T\" but expected type %q\n", fp.t.fpath, v, v, t)
+	getLogger().Error("failpoint: got value of unexpected type", "failpoint", fp.name,
+		"value", v, "type", fmt.Sprintf("%T", v), "expected", t)
 }
 
-// SetTerm sets the terms for this failpoint.
+// SetTerm sets the terms for this failpoint. Goroutines blocked in the
+// actions of the replaced terms are released.
 func (fp *Failpoint) SetTerm(t *terms) {
 	fp.mux.Lock()
 	defer fp.mux.Unlock()
 
+	if fp.t != nil {
+		fp.t.stop()
+	}
 	fp.t = t
 }
 
 // ClearTerm clears the terms for this failpoint, effectively disabling it.
+// Goroutines blocked in the actions of the cleared terms are released.
 func (fp *Failpoint) ClearTerm() error {
 	fp.mux.Lock()
 	defer fp.mux.Unlock()
@@ -73,12 +128,38 @@ func (fp *Failpoint) ClearTerm() error {
 	if fp.t == nil {
 		return ErrDisabled
 	}
+	fp.t.stop()
 	fp.t = nil
 
 	return nil
 }
 
+// Resume releases the goroutines currently paused by a pause action of the failpoint.
+func (fp *Failpoint) Resume() error {
+	fp.mux.RLock()
+	defer fp.mux.RUnlock()
+
+	if fp.t == nil {
+		return ErrDisabled
+	}
+	fp.t.resumePaused()
+	return nil
+}
+
+// Paused returns the number of goroutines currently paused by the failpoint.
+func (fp *Failpoint) Paused() int {
+	fp.mux.RLock()
+	defer fp.mux.RUnlock()
+
+	if fp.t == nil {
+		return 0
+	}
+	return fp.t.pausedCount()
+}
+
 // Status returns the failpoint's status description, execution counter, and error if disabled.
+// The description is annotated with the remaining state of stateful modifiers, such as the
+// number of evaluations left to skip.
 func (fp *Failpoint) Status() (string, int, error) {
 	fp.mux.RLock()
 	defer fp.mux.RUnlock()
@@ -88,5 +169,6 @@ func (fp *Failpoint) Status() (string, int, error) {
 		return "", 0, ErrDisabled
 	}
 
-	return t.desc, t.counter, nil
+	desc, counter := t.status()
+	return desc, counter, nil
 }
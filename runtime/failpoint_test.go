@@ -15,7 +15,13 @@
 package runtime
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,13 +29,13 @@ import (
 
 func TestFailpointCreateAndAcquire(t *testing.T) {
 	name := "failpoint"
-	envTerms = map[string]string{name: "return(1)"}
+	defaultRegistry.envTerms = map[string]string{name: "return(1)"}
 	defer clearGlobalVars()
 
 	fp1 := NewFailpoint("failpoint")
 
 	assert.NotNil(t, fp1.t)
-	assert.Equal(t, envTerms[name], fp1.t.desc)
+	assert.Equal(t, defaultRegistry.envTerms[name], fp1.t.desc)
 
 	v, err := fp1.Acquire()
 	require.NoError(t, err)
@@ -40,16 +46,229 @@ func TestFailpointCreateAndAcquire(t *testing.T) {
 
 func TestSameFailpointCreateTwice(t *testing.T) {
 	name := "failpoint"
-	envTerms = map[string]string{name: "print"}
+	defaultRegistry.envTerms = map[string]string{name: "print"}
 	defer clearGlobalVars()
 
 	NewFailpoint("failpoint")
 	assert.Panics(t, func() { NewFailpoint("failpoint") })
 }
 
+func TestFailpointPause(t *testing.T) {
+	name := "failpoint"
+	defer clearGlobalVars()
+
+	fp := NewFailpoint(name)
+	require.NoError(t, Enable(name, "pause"))
+
+	acquireN := func(n int) *sync.WaitGroup {
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := fp.Acquire()
+				assert.ErrorIs(t, err, ErrDisabled)
+			}()
+		}
+		return &wg
+	}
+	waitPaused := func(n int) {
+		require.Eventually(t, func() bool {
+			paused, err := Paused(name)
+			return err == nil && paused == n
+		}, time.Second, time.Millisecond)
+	}
+
+	// resuming releases the paused goroutines
+	wg := acquireN(3)
+	waitPaused(3)
+	require.NoError(t, Resume(name))
+	wg.Wait()
+	waitPaused(0)
+
+	// goroutines reaching the failpoint after resuming are paused again
+	wg = acquireN(2)
+	waitPaused(2)
+
+	// disabling releases the paused goroutines
+	require.NoError(t, Disable(name))
+	wg.Wait()
+	waitPaused(0)
+
+	require.ErrorIs(t, Resume(name), ErrDisabled)
+	_, err := Paused("nonexistent")
+	require.ErrorIs(t, err, ErrNoExist)
+}
+
+func TestFailpointSleepInterrupted(t *testing.T) {
+	name := "failpoint"
+	defer clearGlobalVars()
+
+	fp := NewFailpoint(name)
+	for _, stop := range []func() error{
+		func() error { return Disable(name) },
+		func() error { return Enable(name, "off") },
+	} {
+		require.NoError(t, Enable(name, `sleep("10m")&return("x")`))
+		done := make(chan error, 1)
+		go func() {
+			_, err := fp.Acquire()
+			done <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		require.NoError(t, stop())
+		select {
+		case err := <-done:
+			assert.ErrorIsf(t, err, ErrDisabled, "the actions after an interrupted sleep should not run")
+		case <-time.After(time.Second):
+			t.Fatal("sleep was not interrupted")
+		}
+	}
+}
+
+func TestFailpointStress(t *testing.T) {
+	defer clearGlobalVars()
+	stressSeed, stressEnabled = 42, true
+	defer func() { stressSeed, stressEnabled = 0, false }()
+
+	fp := NewFailpoint("failpoint")
+	require.NotNil(t, fp.stress)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := fp.Acquire()
+				assert.ErrorIs(t, err, ErrDisabled)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// failpoints with terms are not perturbed
+	require.NoError(t, Enable("failpoint", "return(1)"))
+	v, err := fp.Acquire()
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+}
+
+func TestTypedFailpointAcquire(t *testing.T) {
+	defer clearGlobalVars()
+
+	tests := []struct {
+		varType string
+		terms   string
+		want    interface{}
+	}{
+		{"int64", "return(-1)", int64(-1)},
+		{"float64", "return(1)", 1.0},
+		{"time.Duration", "return(250ms)", 250 * time.Millisecond},
+		{"time.Duration", `return("1s")`, time.Second},
+		{"[]byte", `return("abc")`, []byte("abc")},
+		{"[]string", `return([]string{"a","b"})`, []string{"a", "b"}},
+		{"map[string]int", `return(map[string]int{"a":1})`, map[string]int{"a": 1}},
+		{"[]int", `return(nil)`, []int(nil)},
+		{"MyType", "return(1)", 1},
+	}
+	for i, tt := range tests {
+		name := fmt.Sprintf("failpoint%d", i)
+		fp := NewTypedFailpoint(name, tt.varType)
+		require.NoError(t, Enable(name, tt.terms))
+
+		v, err := fp.Acquire()
+		require.NoErrorf(t, err, "failed to acquire %s", tt.terms)
+		assert.Equalf(t, tt.want, v, "unexpected value of %s for a %s failpoint", tt.terms, tt.varType)
+	}
+}
+
+func TestEnableBadType(t *testing.T) {
+	defer clearGlobalVars()
+
+	NewTypedFailpoint("int", "int")
+	NewTypedFailpoint("error", "error")
+	NewTypedFailpoint("struct", "struct{}")
+	NewTypedFailpoint("custom", "MyType")
+	NewFailpoint("untyped")
+	tests := []struct {
+		name  string
+		terms string
+		ok    bool
+	}{
+		{"int", "return(1)", true},
+		{"int", "return(2.0)->sleep(10)&print", true},
+		{"int", "return(@value)", true},
+		{"int", "return(1.5)", false},
+		{"int", `1*return(1)->return("1")`, false},
+		{"int", "return", false},
+		{"int", "error", false},
+		{"error", `error("disk full")`, true},
+		{"error", "return(io.EOF)", true},
+		{"error", "return(1)", false},
+		{"struct", "return", true},
+		{"struct", "sleep(1)&print", true},
+		{"struct", "return(true)", false},
+		{"custom", "return(1)", true},
+		{"custom", "return(nil)", false},
+		{"untyped", "return(nil)", false},
+		{"untyped", "return([]any{1, nil})", true},
+		{"error", "return(nil)", false},
+	}
+	for _, tt := range tests {
+		err := Enable(tt.name, tt.terms)
+		if tt.ok {
+			assert.NoErrorf(t, err, "expected %q to be accepted by %s failpoint", tt.terms, tt.name)
+		} else {
+			assert.ErrorIsf(t, err, ErrBadType, "expected %q to be rejected by %s failpoint", tt.terms, tt.name)
+		}
+	}
+
+	require.NoError(t, Enable("int", "return(1)"))
+	require.Error(t, Enable("int", `return("1")`))
+	status, _, err := Status("int")
+	require.NoError(t, err)
+	assert.Equalf(t, "return(1)", status, "rejected terms should not replace the enabled ones")
+}
+
+func TestHTTPType(t *testing.T) {
+	defer clearGlobalVars()
+
+	NewTypedFailpoint("typed", "map[string]int")
+	NewFailpoint("untyped")
+
+	varType, err := Type("typed")
+	require.NoError(t, err)
+	assert.Equal(t, "map[string]int", varType)
+	_, err = Type("missing")
+	require.ErrorIs(t, err, ErrNoExist)
+
+	for name, body := range map[string]string{"typed": "map[string]int\n", "untyped": "\n"} {
+		rec := httptest.NewRecorder()
+		defaultRegistry.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+name+"/type", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, body, rec.Body.String())
+	}
+
+	assert.Equal(t, map[string]string{"typed": "map[string]int", "untyped": ""}, Types())
+	rec := httptest.NewRecorder()
+	defaultRegistry.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/-/types", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "typed=map[string]int\nuntyped=\n", rec.Body.String())
+	assert.NotPanicsf(t, func() { NewFailpoint("types") }, "the endpoint should not reserve the name")
+
+	rec = httptest.NewRecorder()
+	defaultRegistry.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/typed", strings.NewReader(`return(map[string]int{"a":-1})`)))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = httptest.NewRecorder()
+	defaultRegistry.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/typed", strings.NewReader(`return([]int{1})`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "does not evaluate to map[string]int")
+}
+
 // clearGlobalVars will unset runtime package global variables
 // note: doesn't work if tests are run in parallel
 func clearGlobalVars() {
-	envTerms = make(map[string]string)
-	failpoints = make(map[string]*Failpoint)
+	defaultRegistry = NewRegistry()
 }
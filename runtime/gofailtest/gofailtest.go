@@ -0,0 +1,107 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gofailtest provides helpers for enabling failpoints in Go tests.
+package gofailtest
+
+import (
+	"errors"
+	"testing"
+
+	"go.etcd.io/gofail/runtime"
+)
+
+// Failpoints runs the helpers against the failpoints of a registry.
+type Failpoints struct {
+	reg *runtime.Registry
+}
+
+// In returns the helpers for the failpoints of the given registry.
+func In(reg *runtime.Registry) Failpoints {
+	return Failpoints{reg: reg}
+}
+
+// Enable sets the terms of a failpoint of the default registry for the
+// duration of the test, see Failpoints.Enable.
+func Enable(t testing.TB, name, terms string) {
+	t.Helper()
+	In(runtime.DefaultRegistry()).Enable(t, name, terms)
+}
+
+// AssertTriggered checks the execution count of a failpoint of the default
+// registry, see Failpoints.AssertTriggered.
+func AssertTriggered(t testing.TB, name string, n int) bool {
+	t.Helper()
+	return In(runtime.DefaultRegistry()).AssertTriggered(t, name, n)
+}
+
+// CheckLeaks fails the test if a failpoint of the default registry is still
+// enabled when it finishes, see Failpoints.CheckLeaks.
+func CheckLeaks(t testing.TB) {
+	t.Helper()
+	In(runtime.DefaultRegistry()).CheckLeaks(t)
+}
+
+// Enable sets the terms of a failpoint, failing the test immediately if the
+// failpoint does not exist or the terms are rejected. The failpoint is
+// disabled when the test and its subtests finish.
+func (f Failpoints) Enable(t testing.TB, name, terms string) {
+	t.Helper()
+	if err := f.reg.Enable(name, terms); err != nil {
+		t.Fatalf("failed to enable failpoint %s with %q: %v", name, terms, err)
+		return
+	}
+	t.Cleanup(func() {
+		if err := f.reg.Disable(name); err != nil && !errors.Is(err, runtime.ErrDisabled) {
+			t.Errorf("failed to disable failpoint %s: %v", name, err)
+		}
+	})
+}
+
+// AssertTriggered checks that the terms of a failpoint were executed n times
+// since it was enabled, as counted by Status. A disabled failpoint counts as
+// executed 0 times. It reports an error and returns false otherwise.
+func (f Failpoints) AssertTriggered(t testing.TB, name string, n int) bool {
+	t.Helper()
+	_, count, err := f.reg.Status(name)
+	if err != nil && (!errors.Is(err, runtime.ErrDisabled) || n != 0) {
+		t.Errorf("failed to get the status of failpoint %s: %v", name, err)
+		return false
+	}
+	if count != n {
+		t.Errorf("failpoint %s was triggered %d times, expected %d", name, count, n)
+		return false
+	}
+	return true
+}
+
+// CheckLeaks fails the test if a failpoint is still enabled when the test
+// finishes. The failpoints enabled by Enable are disabled before the check
+// when CheckLeaks is called first, e.g.
+//
+//	func TestSomething(t *testing.T) {
+//		gofailtest.CheckLeaks(t)
+//		gofailtest.Enable(t, "SomeFuncString", `return("hello")`)
+//		......
+//	}
+func (f Failpoints) CheckLeaks(t testing.TB) {
+	t.Helper()
+	t.Cleanup(func() {
+		for _, name := range f.reg.List() {
+			if status, _, err := f.reg.Status(name); err == nil {
+				t.Errorf("failpoint %s is still enabled with %q", name, status)
+			}
+		}
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gofailtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/gofail/runtime"
+)
+
+// fakeTB records the failures and cleanups of a test.
+type fakeTB struct {
+	testing.TB
+	errors   []string
+	fatal    bool
+	cleanups []func()
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) Fatalf(format string, args ...any) {
+	tb.Errorf(format, args...)
+	tb.fatal = true
+}
+
+func (tb *fakeTB) Cleanup(fn func()) {
+	tb.cleanups = append(tb.cleanups, fn)
+}
+
+// finish runs the cleanups in the reverse order of their registration.
+func (tb *fakeTB) finish() {
+	for i := len(tb.cleanups) - 1; i >= 0; i-- {
+		tb.cleanups[i]()
+	}
+	tb.cleanups = nil
+}
+
+func TestEnable(t *testing.T) {
+	reg := runtime.NewRegistry()
+	fp := reg.NewTypedFailpoint("enabled", "int")
+	f := In(reg)
+
+	tb := &fakeTB{}
+	f.Enable(tb, "enabled", "return(1)")
+	require.Empty(t, tb.errors)
+	v, err := fp.Acquire()
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+	tb.finish()
+	assert.Empty(t, tb.errors)
+	_, err = fp.Acquire()
+	assert.ErrorIsf(t, err, runtime.ErrDisabled, "the failpoint should be disabled by the cleanup")
+
+	for _, terms := range []string{"return(", `return("1")`} {
+		tb = &fakeTB{}
+		f.Enable(tb, "enabled", terms)
+		assert.Truef(t, tb.fatal, "expected enabling %q to fail the test", terms)
+		assert.Empty(t, tb.cleanups)
+	}
+	tb = &fakeTB{}
+	f.Enable(tb, "missing", "return(1)")
+	assert.True(t, tb.fatal)
+}
+
+func TestAssertTriggered(t *testing.T) {
+	reg := runtime.NewRegistry()
+	fp := reg.NewFailpoint("triggered")
+	f := In(reg)
+
+	tb := &fakeTB{}
+	assert.Truef(t, f.AssertTriggered(tb, "triggered", 0), "a disabled failpoint should count as not triggered")
+	assert.False(t, f.AssertTriggered(tb, "triggered", 1))
+	assert.False(t, f.AssertTriggered(tb, "missing", 0))
+	assert.Len(t, tb.errors, 2)
+
+	tb = &fakeTB{}
+	f.Enable(tb, "triggered", "2*return(1)")
+	for i := 0; i < 3; i++ {
+		fp.Acquire()
+	}
+	assert.True(t, f.AssertTriggered(tb, "triggered", 2))
+	assert.False(t, f.AssertTriggered(tb, "triggered", 3))
+	assert.Equal(t, []string{"failpoint triggered was triggered 2 times, expected 3"}, tb.errors)
+	tb.finish()
+}
+
+func TestCheckLeaks(t *testing.T) {
+	reg := runtime.NewRegistry()
+	reg.NewFailpoint("leaked")
+	reg.NewFailpoint("cleaned")
+	f := In(reg)
+
+	tb := &fakeTB{}
+	f.CheckLeaks(tb)
+	f.Enable(tb, "cleaned", "return(1)")
+	tb.finish()
+	assert.Emptyf(t, tb.errors, "failpoints enabled by Enable should not leak")
+
+	tb = &fakeTB{}
+	f.CheckLeaks(tb)
+	f.Enable(tb, "cleaned", "return(1)")
+	require.NoError(t, reg.Enable("leaked", "return(1)"))
+	tb.finish()
+	assert.Equal(t, []string{`failpoint leaked is still enabled with "return(1)"`}, tb.errors)
+}
+
+// fpDefault is registered once, so the tests survive -count and -cpu repeats.
+var fpDefault = runtime.NewFailpoint("gofailtest")
+
+func TestDefaultRegistry(t *testing.T) {
+	fp := fpDefault
+	CheckLeaks(t)
+
+	t.Run("enable", func(t *testing.T) {
+		Enable(t, "gofailtest", `return("x")`)
+		v, err := fp.Acquire()
+		require.NoError(t, err)
+		assert.Equal(t, "x", v)
+		AssertTriggered(t, "gofailtest", 1)
+	})
+	AssertTriggered(t, "gofailtest", 0)
+}
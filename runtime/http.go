@@ -18,31 +18,23 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 )
 
-type httpHandler struct{}
+// Header is the request header read by Middleware, in the format of
+// GOFAIL_FAILPOINTS, e.g. X-Gofail: name=return("x");other=sleep(10)
+const Header = "X-Gofail"
 
-func serve(host string) error {
-	ln, err := net.Listen("tcp", host)
-	if err != nil {
-		return err
-	}
-	go http.Serve(ln, &httpHandler{})
-	return nil
-}
-
-func (*httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+// ServeHTTP serves the failpoints of the registry, see GOFAIL_HTTP.
+func (reg *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Ensures the server(runtime) doesn't panic due to the execution of
 	// panic failpoints during processing of the HTTP request, as the
 	// sender of the HTTP request should not be affected by the execution
 	// of the panic failpoints and crash as a side effect
-	panicMu.Lock()
-	defer panicMu.Unlock()
+	reg.panicMu.Lock()
+	defer reg.panicMu.Unlock()
 
 	// flush before unlocking so a panic failpoint won't
 	// take down the http server before it sends the response
@@ -74,7 +66,7 @@ func (*httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		for k, v := range fpMap {
-			if err := Enable(k, v); err != nil {
+			if err := reg.Enable(k, v); err != nil {
 				http.Error(w, fmt.Sprintf("fail to set failpoint: %v", err), http.StatusBadRequest)
 				return
 			}
@@ -83,18 +75,28 @@ func (*httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// gets status of the failpoint
 	case "GET":
-		if len(key) == 0 {
-			fps := list()
-			sort.Strings(fps)
+		// endpoints under "-/" are not shadowed by failpoints named after them
+		if key == "-/actions" {
+			w.Write([]byte(strings.Join(Actions(), "\n") + "\n"))
+		} else if key == "-/types" {
+			types := reg.Types()
+			fps := reg.List()
+			lines := make([]string, len(fps))
+			for i, fp := range fps {
+				lines[i] = fp + "=" + types[fp]
+			}
+			w.Write([]byte(strings.Join(lines, "\n") + "\n"))
+		} else if len(key) == 0 {
+			fps := reg.List()
 			lines := make([]string, len(fps))
 			for i := range lines {
-				s, _, _ := Status(fps[i])
+				s, _, _ := reg.Status(fps[i])
 				lines[i] = fps[i] + "=" + s
 			}
 			w.Write([]byte(strings.Join(lines, "\n") + "\n"))
 		} else if strings.HasSuffix(key, "/count") {
 			fp := key[:len(key)-len("/count")]
-			_, count, err := Status(fp)
+			_, count, err := reg.Status(fp)
 			if err != nil {
 				if errors.Is(err, ErrNoExist) {
 					http.Error(w, "failed to GET: "+err.Error(), http.StatusNotFound)
@@ -104,17 +106,50 @@ func (*httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			w.Write([]byte(strconv.Itoa(count)))
+		} else if strings.HasSuffix(key, "/paused") {
+			fp := key[:len(key)-len("/paused")]
+			paused, err := reg.Paused(fp)
+			if err != nil {
+				http.Error(w, "failed to GET: "+err.Error(), http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(strconv.Itoa(paused)))
+		} else if strings.HasSuffix(key, "/type") {
+			fp := key[:len(key)-len("/type")]
+			varType, err := reg.Type(fp)
+			if err != nil {
+				http.Error(w, "failed to GET: "+err.Error(), http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(varType + "\n"))
 		} else {
-			status, _, err := Status(key)
+			status, _, err := reg.Status(key)
 			if err != nil {
 				http.Error(w, "failed to GET: "+err.Error(), http.StatusNotFound)
 			}
 			w.Write([]byte(status + "\n"))
 		}
 
+	// releases the goroutines paused by a failpoint
+	case "POST":
+		if !strings.HasSuffix(key, "/resume") {
+			http.Error(w, "malformed request URI", http.StatusBadRequest)
+			return
+		}
+		fp := key[:len(key)-len("/resume")]
+		if err := reg.Resume(fp); err != nil {
+			if errors.Is(err, ErrNoExist) {
+				http.Error(w, "failed to resume failpoint "+err.Error(), http.StatusNotFound)
+			} else {
+				http.Error(w, "failed to resume failpoint "+err.Error(), http.StatusBadRequest)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
 	// deactivates a failpoint
 	case "DELETE":
-		if err := Disable(key); err != nil {
+		if err := reg.Disable(key); err != nil {
 			http.Error(w, "failed to delete failpoint "+err.Error(), http.StatusBadRequest)
 			return
 		}
@@ -122,7 +157,8 @@ func (*httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	default:
 		w.Header().Add("Allow", "DELETE")
 		w.Header().Add("Allow", "GET")
-		w.Header().Set("Allow", "PUT")
+		w.Header().Add("Allow", "POST")
+		w.Header().Add("Allow", "PUT")
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
@@ -132,3 +168,41 @@ func flush(w http.ResponseWriter) {
 		f.Flush()
 	}
 }
+
+// Middleware returns a handler attaching the terms given by the Header of
+// each request to the request context for the failpoints of the default
+// registry, see Registry.Middleware.
+func Middleware(next http.Handler) http.Handler {
+	return defaultRegistry.Middleware(next)
+}
+
+// Middleware returns a handler attaching the terms given by the Header of
+// each request to the request context, as WithFailpoint does, before calling
+// next. Failpoints evaluated with AcquireCtx and the request context then
+// execute these terms, so a single request can be faulted without touching
+// the terms set by Enable. Requests with malformed terms, or terms naming an
+// unknown failpoint, are rejected with 400 Bad Request.
+func (reg *Registry) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values := r.Header.Values(Header)
+		if len(values) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		for _, v := range values {
+			fpMap, err := parseFailpoints(v)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("fail to parse %s header: %v", Header, err), http.StatusBadRequest)
+				return
+			}
+			for name, terms := range fpMap {
+				if ctx, err = reg.WithFailpoint(ctx, name, terms); err != nil {
+					http.Error(w, fmt.Sprintf("fail to set failpoint %s: %v", name, err), http.StatusBadRequest)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
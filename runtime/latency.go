@@ -0,0 +1,73 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"math/rand"
+	"time"
+)
+
+// latency is a distribution of sleep durations, drawn from the random
+// source of the terms on every execution.
+type latency interface {
+	sample(t *terms) time.Duration
+}
+
+// uniformLatency draws durations uniformly in [min, max].
+type uniformLatency struct {
+	min, max time.Duration
+}
+
+func (l *uniformLatency) sample(t *terms) time.Duration {
+	return l.min + time.Duration(t.randInt63n(int64(l.max-l.min)+1))
+}
+
+// expLatency draws durations from an exponential distribution of the given
+// mean, modeling the latency of independent events.
+type expLatency struct {
+	mean time.Duration
+}
+
+func (l *expLatency) sample(t *terms) time.Duration {
+	return time.Duration(t.randFloat64((*rand.Rand).ExpFloat64) * float64(l.mean))
+}
+
+// normalLatency draws durations from a normal distribution, truncated at 0.
+type normalLatency struct {
+	mean, stddev time.Duration
+}
+
+func (l *normalLatency) sample(t *terms) time.Duration {
+	d := time.Duration(t.randFloat64((*rand.Rand).NormFloat64)*float64(l.stddev)) + l.mean
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// toDuration converts a term value to a duration, an int being a number of
+// milliseconds as for sleep.
+func toDuration(v interface{}) (time.Duration, bool) {
+	switch v := v.(type) {
+	case int:
+		return time.Duration(v) * time.Millisecond, true
+	case time.Duration:
+		return v, true
+	case string:
+		d, err := time.ParseDuration(v)
+		return d, err == nil
+	}
+	return 0, false
+}
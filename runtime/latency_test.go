@@ -0,0 +1,79 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencySample(t *testing.T) {
+	defer SetSeed(Seed())
+	SetSeed(42)
+
+	sampleN := func(desc string, n int) []time.Duration {
+		ter, err := newTerms("test", desc)
+		require.NoErrorf(t, err, "failed to parse %q", desc)
+		l, ok := ter.chain[0].acts[0].val.(latency)
+		require.Truef(t, ok, "expected %q to sleep a latency distribution", desc)
+		ret := make([]time.Duration, n)
+		for i := range ret {
+			ret[i] = l.sample(ter)
+		}
+		return ret
+	}
+	mean := func(ds []time.Duration) time.Duration {
+		var sum time.Duration
+		for _, d := range ds {
+			sum += d
+		}
+		return sum / time.Duration(len(ds))
+	}
+
+	uniform := sampleN(`sleep("10ms".."20ms")`, 1000)
+	for _, d := range uniform {
+		assert.GreaterOrEqual(t, d, 10*time.Millisecond)
+		assert.LessOrEqual(t, d, 20*time.Millisecond)
+	}
+	assert.InDelta(t, 15*time.Millisecond, mean(uniform), float64(time.Millisecond))
+
+	assert.Equal(t, []time.Duration{5 * time.Millisecond, 5 * time.Millisecond}, sampleN("sleep(5..5)", 2))
+
+	exp := sampleN(`sleep(exp("50ms"))`, 10000)
+	for _, d := range exp {
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+	}
+	assert.InDelta(t, 50*time.Millisecond, mean(exp), float64(3*time.Millisecond))
+
+	normal := sampleN(`sleep(normal("100ms","20ms"))`, 10000)
+	assert.InDelta(t, 100*time.Millisecond, mean(normal), float64(2*time.Millisecond))
+	for _, d := range sampleN(`sleep(normal(1ms,10ms))`, 1000) {
+		assert.GreaterOrEqualf(t, d, time.Duration(0), "normal latencies should be truncated at 0")
+	}
+
+	SetSeed(42)
+	assert.Equalf(t, uniform, sampleN(`sleep("10ms".."20ms")`, 1000), "same seed should replay the same latencies")
+}
+
+func TestLatencySleep(t *testing.T) {
+	ter, err := newTerms("test", `sleep(1ms..2ms)&return(1)->sleep(exp(1ms))`)
+	require.NoError(t, err)
+	start := time.Now()
+	assert.Equal(t, 1, ter.eval())
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond)
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	tmplparse "text/template/parse"
+)
+
+// logger is the logger set by SetLogger.
+var logger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger used by the runtime for its messages and for the
+// print and log actions. A nil logger restores the default, slog.Default().
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+func getLogger() *slog.Logger {
+	if l := logger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
+
+// logData is the data available to the template of a log action.
+type logData struct {
+	// Name is the name of the failpoint
+	Name string
+	// Count is the execution count of the failpoint terms
+	Count int
+	// Goroutine is the id of the goroutine executing the action
+	Goroutine int64
+	// Term is the canonical description of the term being executed
+	Term string
+}
+
+// checkLogFields checks that the fields used by a log template are fields of
+// logData, without executing it as execution may not terminate. dot reports
+// whether dot is still the logData, which range and with reassign.
+func checkLogFields(node tmplparse.Node, dot bool) error {
+	switch n := node.(type) {
+	case *tmplparse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, c := range n.Nodes {
+			if err := checkLogFields(c, dot); err != nil {
+				return err
+			}
+		}
+	case *tmplparse.ActionNode:
+		return checkLogFields(n.Pipe, dot)
+	case *tmplparse.IfNode:
+		return checkLogBranch(&n.BranchNode, dot, dot)
+	case *tmplparse.RangeNode:
+		return checkLogBranch(&n.BranchNode, dot, false)
+	case *tmplparse.WithNode:
+		return checkLogBranch(&n.BranchNode, dot, false)
+	case *tmplparse.TemplateNode:
+		if n.Pipe != nil {
+			return checkLogFields(n.Pipe, dot)
+		}
+	case *tmplparse.PipeNode:
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				if err := checkLogFields(arg, dot); err != nil {
+					return err
+				}
+			}
+		}
+	case *tmplparse.FieldNode:
+		if !dot {
+			return fmt.Errorf("field %s of a value other than the log data", n)
+		}
+		return checkLogField(n.Ident)
+	case *tmplparse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			return checkLogField(n.Ident[1:])
+		}
+	case *tmplparse.ChainNode:
+		if len(n.Field) > 0 {
+			return fmt.Errorf("field %s of a value other than the log data", n)
+		}
+		return checkLogFields(n.Node, dot)
+	}
+	return nil
+}
+
+// checkLogBranch checks the pipeline and lists of an if, range or with
+// action, whose list runs with dot reassigned unless inDot is true.
+func checkLogBranch(n *tmplparse.BranchNode, dot, inDot bool) error {
+	if err := checkLogFields(n.Pipe, dot); err != nil {
+		return err
+	}
+	if err := checkLogFields(n.List, dot && inDot); err != nil {
+		return err
+	}
+	return checkLogFields(n.ElseList, dot)
+}
+
+// checkLogField checks a chain of field names on the log data, whose fields
+// have no fields of their own.
+func checkLogField(ident []string) error {
+	if _, ok := reflect.TypeOf(logData{}).FieldByName(ident[0]); !ok || len(ident) > 1 {
+		return fmt.Errorf("unknown log field %s", strings.Join(ident, "."))
+	}
+	return nil
+}
+
+func actLog(_ context.Context, t *term, val interface{}, count int) interface{} {
+	data := logData{Name: t.parent.fpath, Count: count, Goroutine: goroutineID(), Term: t.desc}
+	msg := "failpoint log"
+	if tmpl, ok := val.(*template.Template); ok {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			getLogger().Error("failpoint: could not execute log template", "failpoint", t.parent.fpath, "error", err)
+			return nil
+		}
+		msg = buf.String()
+	}
+	getLogger().Info(msg, "failpoint", data.Name, "count", data.Count, "goroutine", data.Goroutine)
+	return nil
+}
+
+// goroutineID returns the id of the current goroutine, parsed from the
+// "goroutine <id> [<state>]:" header of its stack.
+func goroutineID() int64 {
+	var buf [64]byte
+	s := string(buf[:goruntime.Stack(buf[:], false)])
+	s = strings.TrimPrefix(s, "goroutine ")
+	if i := strings.IndexByte(s, ' '); i > 0 {
+		s = s[:i]
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return id
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogAction(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer SetLogger(nil)
+
+	ter, err := newTerms("test", `1*log("hit {{.Name}} #{{.Count}} on goroutine {{.Goroutine}}")->log->print`)
+	require.NoError(t, err)
+	assert.Equal(t, `1*log("hit {{.Name}} #{{.Count}} on goroutine {{.Goroutine}}")->log->print`, ter.String())
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, ter.eval())
+	}
+	out := buf.String()
+	assert.Contains(t, out, fmt.Sprintf(`level=INFO msg="hit test #1 on goroutine %d"`, goroutineID()))
+	assert.Contains(t, out, `level=INFO msg="failpoint log" failpoint=test count=2`)
+	assert.NotContainsf(t, out, "failpoint print", "terms should stop at the first unbounded term")
+}
+
+func TestLogActionParseError(t *testing.T) {
+	for _, desc := range []string{`log("{{.Name")`, `log("{{.Nope}}")`, `log("{{.Name.Nope}}")`,
+		`log("{{with .Name}}{{.Nope}}{{end}}")`, `log("{{$.Nope}}")`, `log("{{(.Name).Nope}}")`, `log(1)`, `log(true)`} {
+		_, err := newTerms("test", desc)
+		assert.ErrorIsf(t, err, ErrBadParse, "expected %q to fail parsing", desc)
+	}
+}
+
+func TestLogActionTemplateNotExecuted(t *testing.T) {
+	done := make(chan error, 1)
+	go func() {
+		_, err := newTerms("test", `log("{{range 3000000000}}{{end}}{{with .Name}}{{.}}{{end}}{{$.Count}}")`)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		require.NoErrorf(t, err, "expected the template to parse")
+	case <-time.After(5 * time.Second):
+		require.Failf(t, "parse timeout", "parsing should not execute the template")
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer SetLogger(nil)
+
+	ter, err := newTerms("test", `print`)
+	require.NoError(t, err)
+	ter.eval()
+	assert.Contains(t, buf.String(), `level=INFO msg="failpoint print" failpoint=test`)
+
+	SetLogger(nil)
+	assert.Equal(t, slog.Default(), getLogger())
+}
+
+func TestGoroutineID(t *testing.T) {
+	ids := make(chan int64)
+	go func() { ids <- goroutineID() }()
+	id := <-ids
+	assert.Positive(t, id)
+	assert.Positive(t, goroutineID())
+	assert.NotEqual(t, goroutineID(), id)
+}
@@ -0,0 +1,836 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	// ErrBadParse indicates that the failpoint term string could not be parsed.
+	// Parse failures are reported as a *ParseError wrapping ErrBadParse.
+	ErrBadParse = fmt.Errorf("failpoint: could not parse terms")
+)
+
+// ParseError describes where and why a failpoint term string could not be parsed.
+type ParseError struct {
+	// Input is the term string being parsed.
+	Input string
+	// Offset is the byte offset in Input at which parsing failed.
+	Offset int
+	// Expected describes what the parser expected at Offset.
+	Expected string
+	// Found describes what the parser found at Offset.
+	Found string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failpoint: could not parse terms %q at offset %d: expected %s, found %s",
+		e.Input, e.Offset, e.Expected, e.Found)
+}
+
+// Unwrap returns ErrBadParse, so errors.Is(err, ErrBadParse) holds for a *ParseError.
+func (e *ParseError) Unwrap() error { return ErrBadParse }
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokInt
+	tokFloat
+	tokString
+	tokIdent
+	tokLParen
+	tokRParen
+	tokArrow
+	tokPercent
+	tokStar
+	tokPlus
+	tokSlash
+	tokAmp
+	tokLBrack
+	tokRBrack
+	tokLBrace
+	tokRBrace
+	tokComma
+	tokColon
+	tokAt
+	tokDots
+)
+
+var punctTokens = map[byte]tokenKind{
+	'(': tokLParen,
+	')': tokRParen,
+	'%': tokPercent,
+	'*': tokStar,
+	'+': tokPlus,
+	'/': tokSlash,
+	'&': tokAmp,
+	'[': tokLBrack,
+	']': tokRBrack,
+	'{': tokLBrace,
+	'}': tokRBrace,
+	',': tokComma,
+	':': tokColon,
+	'@': tokAt,
+}
+
+type token struct {
+	kind tokenKind
+	// text is the source text of the token
+	text string
+	// pos is the byte offset of the token in the input
+	pos int
+}
+
+func (tok token) String() string {
+	if tok.kind == tokEOF {
+		return "end of input"
+	}
+	return strconv.Quote(tok.text)
+}
+
+// lex splits a term string into tokens, skipping white space.
+func lex(input string) ([]token, error) {
+	var toks []token
+	i := 0
+	for {
+		for i < len(input) && isSpace(input[i]) {
+			i++
+		}
+		if i == len(input) {
+			return append(toks, token{kind: tokEOF, pos: i}), nil
+		}
+		start := i
+		c := input[i]
+		var kind tokenKind
+		switch {
+		case isDigit(c) || (c == '-' && i+1 < len(input) && isDigit(input[i+1])):
+			kind = tokInt
+			i++
+			for i < len(input) && isDigit(input[i]) {
+				i++
+			}
+			if i < len(input) && input[i] == '.' && !strings.HasPrefix(input[i:], "..") {
+				kind = tokFloat
+				i++
+				for i < len(input) && isDigit(input[i]) {
+					i++
+				}
+			}
+			if j := exponentLen(input[i:]); j > 0 {
+				kind = tokFloat
+				i += j
+			}
+		case isLetter(c):
+			kind = tokIdent
+			for i < len(input) && (isLetter(input[i]) || isDigit(input[i]) ||
+				(input[i] == '.' && !strings.HasPrefix(input[i:], ".."))) {
+				i++
+			}
+		case c == '"' || c == '`':
+			q, err := strconv.QuotedPrefix(input[i:])
+			if err != nil {
+				return nil, &ParseError{Input: input, Offset: i, Expected: "a terminated string", Found: strconv.Quote(input[i:])}
+			}
+			kind = tokString
+			i += len(q)
+		case c == '-' && strings.HasPrefix(input[i:], "->"):
+			kind = tokArrow
+			i += 2
+		case c == '.' && strings.HasPrefix(input[i:], ".."):
+			kind = tokDots
+			i += 2
+		default:
+			k, ok := punctTokens[c]
+			if !ok {
+				return nil, &ParseError{Input: input, Offset: i, Expected: "a token", Found: strconv.Quote(input[i : i+1])}
+			}
+			kind = k
+			i++
+		}
+		toks = append(toks, token{kind: kind, text: input[start:i], pos: start})
+	}
+}
+
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
+
+// exponentLen returns the length of the exponent of a float at the start of
+// s, such as "e3" or "E-3", or 0 if there is none.
+func exponentLen(s string) int {
+	if len(s) < 2 || (s[0] != 'e' && s[0] != 'E') {
+		return 0
+	}
+	i := 1
+	if s[i] == '+' || s[i] == '-' {
+		i++
+	}
+	if i == len(s) || !isDigit(s[i]) {
+		return 0
+	}
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool  { return '0' <= c && c <= '9' }
+func isLetter(c byte) bool { return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') }
+
+// parser builds a terms chain out of tokens. Every parsed element also
+// produces its canonical text, which is used as the term description.
+type parser struct {
+	input string
+	toks  []token
+	i     int
+}
+
+// parse parses a term string into a terms chain.
+// <fp> :: <term> ( "->" <term> )*
+func parse(desc string) ([]*term, error) {
+	toks, err := lex(desc)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{input: desc, toks: toks}
+	var chain []*term
+	for {
+		t, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, t)
+		switch p.peek().kind {
+		case tokArrow:
+			p.next()
+		case tokEOF:
+			return chain, nil
+		default:
+			return nil, p.errorf(p.peek(), `"&", "->" or end of input`)
+		}
+	}
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	tok := p.toks[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+func (p *parser) expect(kind tokenKind, expected string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, p.errorf(tok, expected)
+	}
+	return tok, nil
+}
+
+func (p *parser) errorf(tok token, expected string) error {
+	return &ParseError{Input: p.input, Offset: tok.pos, Expected: expected, Found: tok.String()}
+}
+
+// <term> :: ( <mod>+ "->" )* <mod>* <act> ( "&" <act> )*
+//
+// Mods followed by "->" apply to the next term, as in after("5s")->return(1).
+func (p *parser) parseTerm() (*term, error) {
+	var mods []mod
+	desc := ""
+	for {
+		m, s, err := p.parseMod()
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			if len(mods) == 0 || p.peek().kind != tokArrow {
+				break
+			}
+			p.next()
+			continue
+		}
+		mods = append(mods, m)
+		desc += s
+	}
+
+	var acts []action
+	for {
+		a, s, err := p.parseAct()
+		if err != nil {
+			return nil, err
+		}
+		acts = append(acts, a)
+		desc += s
+		if p.peek().kind != tokAmp {
+			break
+		}
+		p.next()
+		desc += "&"
+	}
+	return &term{desc: desc, mods: &modList{mods}, acts: acts}, nil
+}
+
+// <act> :: <name> [ "(" [ <val> ] ")" ]
+func (p *parser) parseAct() (action, string, error) {
+	actTok, err := p.expect(tokIdent, "an action")
+	if err != nil {
+		return action{}, "", err
+	}
+	fn, ok := lookupAction(actTok.text)
+	if !ok {
+		return action{}, "", p.errorf(actTok, "an action")
+	}
+	desc := actTok.text
+
+	var val interface{} = struct{}{}
+	valTok := p.peek()
+	if valTok.kind == tokLParen {
+		p.next()
+		valTok = p.peek()
+		if valTok.kind != tokRParen {
+			v, s, err := p.parseActVal()
+			if err != nil {
+				return action{}, "", err
+			}
+			val = v
+			desc += "(" + s + ")"
+		}
+		if _, err := p.expect(tokRParen, `")"`); err != nil {
+			return action{}, "", err
+		}
+	}
+
+	switch actTok.text {
+	case "error":
+		// error("s") => errors.New("s")
+		switch v := val.(type) {
+		case string:
+			val = errors.New(v)
+		case error, struct{}, valueRef:
+		default:
+			return action{}, "", p.errorf(valTok, "a string or an error")
+		}
+	case "log":
+		// log("s") => template of the message
+		switch v := val.(type) {
+		case string:
+			tmpl, err := template.New("log").Option("missingkey=error").Parse(v)
+			if err == nil {
+				// catch unknown fields, which only fail on execution
+				err = checkLogFields(tmpl.Tree.Root, true)
+			}
+			if err != nil {
+				return action{}, "", p.errorf(valTok, "a valid template")
+			}
+			val = tmpl
+		case struct{}:
+		default:
+			return action{}, "", p.errorf(valTok, "a template string")
+		}
+	case "signal":
+		// signal("SIGUSR1") => a signal known on this platform
+		switch v := val.(type) {
+		case string:
+			if _, ok := lookupSignal(v); !ok {
+				return action{}, "", p.errorf(valTok, "a signal name")
+			}
+		case valueRef:
+		default:
+			return action{}, "", p.errorf(valTok, "a signal name")
+		}
+	case "exit":
+		switch val.(type) {
+		case int, struct{}, valueRef:
+		default:
+			return action{}, "", p.errorf(valTok, "an exit code")
+		}
+	case "fds":
+		switch v := val.(type) {
+		case int:
+			if v < 0 {
+				return action{}, "", p.errorf(valTok, "a non-negative integer")
+			}
+		case valueRef:
+		default:
+			return action{}, "", p.errorf(valTok, "a non-negative integer")
+		}
+	}
+	if _, ok := val.(latency); ok && actTok.text != "sleep" {
+		// only sleep draws from latency distributions
+		return action{}, "", p.errorf(valTok, "a value")
+	}
+	return action{name: actTok.text, fn: fn, val: val}, desc, nil
+}
+
+// parseMod parses a single mod, returning a nil mod if there is none.
+// <mod> :: (<float> "%") | (<int> "*") | (<int> "+") | (<int> "/" <int>) | <timemod>
+func (p *parser) parseMod() (mod, string, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokIdent:
+		if tok.text == "after" || tok.text == "for" {
+			return p.parseTimeMod()
+		}
+		return nil, "", nil
+	case tokInt, tokFloat:
+	default:
+		return nil, "", nil
+	}
+	p.next()
+	if strings.HasPrefix(tok.text, "-") {
+		return nil, "", p.errorf(tok, "a non-negative number")
+	}
+
+	op := p.next()
+	if op.kind == tokPercent {
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, "", p.errorf(tok, "a percentage")
+		}
+		return &modProb{f / 100.0}, strconv.FormatFloat(f, 'f', -1, 64) + "%", nil
+	}
+	if tok.kind != tokInt {
+		return nil, "", p.errorf(op, `"%"`)
+	}
+	v, err := strconv.Atoi(tok.text)
+	if err != nil {
+		return nil, "", p.errorf(tok, "an integer")
+	}
+	s := strconv.Itoa(v)
+	switch op.kind {
+	case tokStar:
+		return &modCount{v}, s + "*", nil
+	case tokPlus:
+		return &modSkip{v}, s + "+", nil
+	case tokSlash:
+		if v == 0 {
+			return nil, "", p.errorf(tok, "a positive integer")
+		}
+		periodTok, err := p.expect(tokInt, "a period")
+		if err != nil {
+			return nil, "", err
+		}
+		period, err := strconv.Atoi(periodTok.text)
+		if err != nil || period < v {
+			return nil, "", p.errorf(periodTok, "a period of at least "+s)
+		}
+		return &modPeriod{k: v, n: period}, s + "/" + strconv.Itoa(period), nil
+	}
+	return nil, "", p.errorf(op, `"%", "*", "+" or "/"`)
+}
+
+// parseTimeMod parses a mod taking its argument in parentheses. The clock
+// of time based mods starts when they are parsed.
+// <timemod> :: ("after" "(" (<string> | <int>) ")") | ("for" "(" <string> ")")
+func (p *parser) parseTimeMod() (mod, string, error) {
+	name := p.next().text
+	if _, err := p.expect(tokLParen, `"("`); err != nil {
+		return nil, "", err
+	}
+	argTok := p.next()
+	var m mod
+	var s string
+	switch {
+	case argTok.kind == tokInt && name == "after":
+		v, err := strconv.Atoi(argTok.text)
+		if err != nil || v < 0 {
+			return nil, "", p.errorf(argTok, "a non-negative integer")
+		}
+		m, s = &modSkip{v}, strconv.Itoa(v)
+	case argTok.kind == tokString:
+		v, err := strconv.Unquote(argTok.text)
+		if err != nil {
+			return nil, "", p.errorf(argTok, "a string")
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, "", p.errorf(argTok, "a non-negative duration")
+		}
+		if name == "after" {
+			m = &modDelay{time.Now().Add(d)}
+		} else {
+			m = &modExpire{time.Now().Add(d)}
+		}
+		s = strconv.Quote(v)
+	case name == "after":
+		return nil, "", p.errorf(argTok, "an integer or a duration")
+	default:
+		return nil, "", p.errorf(argTok, "a duration")
+	}
+	if _, err := p.expect(tokRParen, `")"`); err != nil {
+		return nil, "", err
+	}
+	return m, name + "(" + s + ")", nil
+}
+
+// parseActVal parses the value of an action, which may also name a value
+// registered with RegisterValue or describe a random latency. Named values
+// are resolved when the action is executed, so they may be registered after
+// the terms are parsed.
+// <actval> :: <val> | <range> | <latency> | "@" <name>
+func (p *parser) parseActVal() (interface{}, string, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokIdent && (tok.text == "exp" || tok.text == "normal"):
+		return p.parseLatency()
+	case tok.kind != tokAt:
+		v, s, err := p.parseVal()
+		if err != nil || p.peek().kind != tokDots {
+			return v, s, err
+		}
+		return p.parseRange(tok, v, s)
+	}
+	p.next()
+	tok = p.next()
+	if tok.kind != tokIdent || !isIdent(tok.text) {
+		return nil, "", p.errorf(tok, "a value name")
+	}
+	ref := valueRef(tok.text)
+	return ref, ref.String(), nil
+}
+
+// parseRange parses the upper bound of a uniform latency range.
+// <range> :: <dur> ".." <dur>
+func (p *parser) parseRange(minTok token, minVal interface{}, minDesc string) (interface{}, string, error) {
+	lo, ok := toDuration(minVal)
+	if !ok || lo < 0 {
+		return nil, "", p.errorf(minTok, "a non-negative duration")
+	}
+	p.next()
+	maxTok := p.peek()
+	maxVal, maxDesc, err := p.parseVal()
+	if err != nil {
+		return nil, "", err
+	}
+	hi, ok := toDuration(maxVal)
+	if !ok || hi < lo {
+		return nil, "", p.errorf(maxTok, "a duration of at least "+minDesc)
+	}
+	return &uniformLatency{lo, hi}, minDesc + ".." + maxDesc, nil
+}
+
+// parseLatency parses a latency distribution.
+// <latency> :: "exp" "(" <dur> ")" | "normal" "(" <dur> "," <dur> ")"
+func (p *parser) parseLatency() (interface{}, string, error) {
+	name := p.next().text
+	if _, err := p.expect(tokLParen, `"("`); err != nil {
+		return nil, "", err
+	}
+	var durs []time.Duration
+	var descs []string
+	for {
+		tok := p.peek()
+		v, s, err := p.parseVal()
+		if err != nil {
+			return nil, "", err
+		}
+		d, ok := toDuration(v)
+		if !ok || d < 0 {
+			return nil, "", p.errorf(tok, "a non-negative duration")
+		}
+		durs, descs = append(durs, d), append(descs, s)
+		if name == "exp" || len(durs) == 2 {
+			break
+		}
+		if _, err := p.expect(tokComma, `","`); err != nil {
+			return nil, "", err
+		}
+	}
+	if _, err := p.expect(tokRParen, `")"`); err != nil {
+		return nil, "", err
+	}
+	desc := name + "(" + strings.Join(descs, ",") + ")"
+	if name == "exp" {
+		return &expLatency{durs[0]}, desc, nil
+	}
+	return &normalLatency{durs[0], durs[1]}, desc, nil
+}
+
+// <val> :: <number> | <string> | <bool> | "nil" | <error> | <composite>
+func (p *parser) parseVal() (interface{}, string, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokInt, tokFloat:
+		return p.parseNumber()
+	case tokString:
+		p.next()
+		v, err := strconv.Unquote(tok.text)
+		if err != nil {
+			return nil, "", p.errorf(tok, "a string")
+		}
+		return v, strconv.Quote(v), nil
+	case tokLBrack:
+		return p.parseComposite()
+	case tokIdent:
+		switch tok.text {
+		case "true", "false":
+			p.next()
+			return tok.text == "true", tok.text, nil
+		case "nil":
+			p.next()
+			return nilValue{}, tok.text, nil
+		case "error":
+			p.next()
+			return p.parseErr()
+		case "map":
+			return p.parseComposite()
+		}
+		if e, ok := errSentinels[tok.text]; ok {
+			p.next()
+			return e, tok.text, nil
+		}
+	}
+	return nil, "", p.errorf(p.next(), "a value")
+}
+
+// parseNumber parses a number, which is a duration when directly followed
+// by a unit, e.g. 1h30m or 1.5s.
+// <number> :: <int> | <float> | <duration>
+func (p *parser) parseNumber() (interface{}, string, error) {
+	tok := p.next()
+	if unit := p.peek(); unit.kind == tokIdent && unit.pos == tok.pos+len(tok.text) {
+		p.next()
+		d, err := time.ParseDuration(tok.text + unit.text)
+		if err != nil {
+			return nil, "", p.errorf(tok, "a duration")
+		}
+		// durations lex as a number followed by an identifier, so no "µs"
+		return d, strings.Replace(d.String(), "µs", "us", 1), nil
+	}
+	if tok.kind == tokFloat {
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, "", p.errorf(tok, "a number")
+		}
+		s := strconv.FormatFloat(f, 'f', -1, 64)
+		if abs := math.Abs(f); abs != 0 && (abs < 1e-4 || abs >= 1e21) {
+			// keep very small and large values short
+			s = strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		if !strings.ContainsAny(s, ".e") {
+			// keep the value a float
+			s += ".0"
+		}
+		return f, s, nil
+	}
+	v, err := strconv.Atoi(tok.text)
+	if err != nil {
+		return nil, "", p.errorf(tok, "an integer")
+	}
+	return v, strconv.Itoa(v), nil
+}
+
+// parseType parses a type made of the names in typeNames.
+// <type> :: "[" "]" <type> | "map" "[" <type> "]" <type> | "struct" "{" "}" | "interface" "{" "}" | <typename>
+func (p *parser) parseType() (reflect.Type, string, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokLBrack:
+		if _, err := p.expect(tokRBrack, `"]"`); err != nil {
+			return nil, "", err
+		}
+		elem, s, err := p.parseType()
+		if err != nil {
+			return nil, "", err
+		}
+		return reflect.SliceOf(elem), "[]" + s, nil
+	case tok.kind == tokIdent && tok.text == "map":
+		if _, err := p.expect(tokLBrack, `"["`); err != nil {
+			return nil, "", err
+		}
+		keyTok := p.peek()
+		key, ks, err := p.parseType()
+		if err != nil {
+			return nil, "", err
+		}
+		if !key.Comparable() {
+			return nil, "", p.errorf(keyTok, "a comparable type")
+		}
+		if _, err := p.expect(tokRBrack, `"]"`); err != nil {
+			return nil, "", err
+		}
+		elem, es, err := p.parseType()
+		if err != nil {
+			return nil, "", err
+		}
+		return reflect.MapOf(key, elem), "map[" + ks + "]" + es, nil
+	case tok.kind == tokIdent && (tok.text == "struct" || tok.text == "interface"):
+		if _, err := p.expect(tokLBrace, `"{"`); err != nil {
+			return nil, "", err
+		}
+		if _, err := p.expect(tokRBrace, `"}"`); err != nil {
+			return nil, "", err
+		}
+		if tok.text == "struct" {
+			return reflect.TypeOf(struct{}{}), "struct{}", nil
+		}
+		return typeNames["any"], "interface{}", nil
+	case tok.kind == tokIdent:
+		if typ, ok := typeNames[tok.text]; ok {
+			return typ, tok.text, nil
+		}
+	}
+	return nil, "", p.errorf(tok, "a type")
+}
+
+// parseComposite parses a slice or map literal, or a byte slice conversion.
+// <composite> :: <type> <body> | "[" "]" "byte" "(" <string> ")"
+func (p *parser) parseComposite() (interface{}, string, error) {
+	typ, s, err := p.parseType()
+	if err != nil {
+		return nil, "", err
+	}
+	if isBytes(typ) && p.peek().kind == tokLParen {
+		p.next()
+		tok, err := p.expect(tokString, "a string")
+		if err != nil {
+			return nil, "", err
+		}
+		v, err := strconv.Unquote(tok.text)
+		if err != nil {
+			return nil, "", p.errorf(tok, "a string")
+		}
+		if _, err := p.expect(tokRParen, `")"`); err != nil {
+			return nil, "", err
+		}
+		return []byte(v), s + "(" + strconv.Quote(v) + ")", nil
+	}
+	v, body, err := p.parseBody(typ)
+	if err != nil {
+		return nil, "", err
+	}
+	return v.Interface(), s + body, nil
+}
+
+// parseBody parses the elements of a slice or map literal of the given type.
+// <body> :: "{" [ <elem> { "," <elem> } [ "," ] ] "}"
+// <elem> :: [ <val> ":" ] ( <val> | <body> )
+func (p *parser) parseBody(typ reflect.Type) (reflect.Value, string, error) {
+	if _, err := p.expect(tokLBrace, `"{"`); err != nil {
+		return reflect.Value{}, "", err
+	}
+	out := reflect.New(typ).Elem()
+	if typ.Kind() == reflect.Map {
+		out.Set(reflect.MakeMap(typ))
+	} else {
+		out.Set(reflect.MakeSlice(typ, 0, 0))
+	}
+	var elems []string
+	for p.peek().kind != tokRBrace {
+		s := ""
+		var key reflect.Value
+		if typ.Kind() == reflect.Map {
+			keyTok := p.peek()
+			k, ks, err := p.parseElem(typ.Key())
+			if err != nil {
+				return reflect.Value{}, "", err
+			}
+			if out.MapIndex(k).IsValid() {
+				return reflect.Value{}, "", p.errorf(keyTok, "a unique key")
+			}
+			if _, err := p.expect(tokColon, `":"`); err != nil {
+				return reflect.Value{}, "", err
+			}
+			key, s = k, ks+":"
+		}
+		e, es, err := p.parseElem(typ.Elem())
+		if err != nil {
+			return reflect.Value{}, "", err
+		}
+		if typ.Kind() == reflect.Map {
+			out.SetMapIndex(key, e)
+		} else {
+			out = reflect.Append(out, e)
+		}
+		elems = append(elems, s+es)
+		if p.peek().kind != tokComma {
+			break
+		}
+		p.next()
+	}
+	if _, err := p.expect(tokRBrace, `"," or "}"`); err != nil {
+		return reflect.Value{}, "", err
+	}
+	return out, "{" + strings.Join(elems, ",") + "}", nil
+}
+
+// parseElem parses an element of a composite literal, converted to typ. The
+// type of elements which are slices or maps themselves may be elided.
+func (p *parser) parseElem(typ reflect.Type) (reflect.Value, string, error) {
+	tok := p.peek()
+	if tok.kind == tokLBrace && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map) {
+		return p.parseBody(typ)
+	}
+	v, s, err := p.parseVal()
+	if err != nil {
+		return reflect.Value{}, "", err
+	}
+	rv, ok := convertReflect(reflect.ValueOf(v), typ)
+	if !ok {
+		return reflect.Value{}, "", p.errorf(tok, "a value of type "+typ.String())
+	}
+	return rv, s, nil
+}
+
+// parseErr parses the argument of an error value.
+// <error> :: "error" "(" <string> ")" | <sentinel>
+func (p *parser) parseErr() (interface{}, string, error) {
+	if _, err := p.expect(tokLParen, `"("`); err != nil {
+		return nil, "", err
+	}
+	tok, err := p.expect(tokString, "a string")
+	if err != nil {
+		return nil, "", err
+	}
+	s, err := strconv.Unquote(tok.text)
+	if err != nil {
+		return nil, "", p.errorf(tok, "a string")
+	}
+	if _, err := p.expect(tokRParen, `")"`); err != nil {
+		return nil, "", err
+	}
+	return errors.New(s), "error(" + strconv.Quote(s) + ")", nil
+}
+
+// errSentinels maps the names of well-known error values to the values
+// themselves, so terms can inject errors that satisfy errors.Is.
+var errSentinels = map[string]error{
+	"io.EOF":                   io.EOF,
+	"io.ErrUnexpectedEOF":      io.ErrUnexpectedEOF,
+	"io.ErrClosedPipe":         io.ErrClosedPipe,
+	"io.ErrShortWrite":         io.ErrShortWrite,
+	"io.ErrShortBuffer":        io.ErrShortBuffer,
+	"io.ErrNoProgress":         io.ErrNoProgress,
+	"os.ErrInvalid":            os.ErrInvalid,
+	"os.ErrPermission":         os.ErrPermission,
+	"os.ErrExist":              os.ErrExist,
+	"os.ErrNotExist":           os.ErrNotExist,
+	"os.ErrClosed":             os.ErrClosed,
+	"os.ErrDeadlineExceeded":   os.ErrDeadlineExceeded,
+	"context.Canceled":         context.Canceled,
+	"context.DeadlineExceeded": context.DeadlineExceeded,
+}
@@ -0,0 +1,218 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCanonical(t *testing.T) {
+	tests := []struct {
+		desc      string
+		canonical string
+	}{
+		{`off`, `off`},
+		{`return`, `return`},
+		{`return()`, `return`},
+		{`return(1)`, `return(1)`},
+		{`return("abc")`, `return("abc")`},
+		{`return(true)`, `return(true)`},
+		{`return("a\"b")`, `return("a\"b")`},
+		{"return(`raw`)", `return("raw")`},
+		{"return(`a\\\"b`)", `return("a\\\"b")`},
+		{"error(`disk full`)", `error("disk full")`},
+		{` 2 * return ( "abc" ) -> 1*return("def") `, `2*return("abc")->1*return("def")`},
+		{"10*sleep(10)->\n\t1*return(\"abc\")", `10*sleep(10)->1*return("abc")`},
+		{`40.0%return(true)`, `40%return(true)`},
+		{`1.%panic`, `1%panic`},
+		{`0.5%print`, `0.5%print`},
+		{`return(1e3)`, `return(1000.0)`},
+		{`return(2.5e-3)`, `return(0.0025)`},
+		{`return(-1E+21)`, `return(-1e+21)`},
+		{`return(1e-7)`, `return(1e-07)`},
+		{`5e1%print`, `50%print`},
+		{`100+2*return(1)`, `100+2*return(1)`},
+		{`1 / 3 return(1)`, `1/3return(1)`},
+		{`after( 10 )return(1)`, `after(10)return(1)`},
+		{`after("5s") for("30s") return(1)`, `after("5s")for("30s")return(1)`},
+		{`after("5s")->return(1)`, `after("5s")return(1)`},
+		{`after("5s") -> for("30s") -> return(1)->return(2)`, `after("5s")for("30s")return(1)->return(2)`},
+		{`2* -> return(1)`, `2*return(1)`},
+		{`error( "disk full" )`, `error("disk full")`},
+		{`return(error("disk full"))`, `return(error("disk full"))`},
+		{`error(io.EOF)`, `error(io.EOF)`},
+		{`sleep("10ms")`, `sleep("10ms")`},
+		{`2*sleep( "10ms" ) & return("timeout") -> print & panic`, `2*sleep("10ms")&return("timeout")->print&panic`},
+		{`return(-1)`, `return(-1)`},
+		{`return(-0)`, `return(0)`},
+		{`return(1.50)`, `return(1.5)`},
+		{`return(2.)`, `return(2.0)`},
+		{`return(-0.25)`, `return(-0.25)`},
+		{`return(nil)`, `return(nil)`},
+		{`return(90m)`, `return(1h30m0s)`},
+		{`sleep(1.5s)`, `sleep(1.5s)`},
+		{`sleep(10us)`, `sleep(10us)`},
+		{`return(-5s)`, `return(-5s)`},
+		{`return([]byte("abc"))`, `return([]byte("abc"))`},
+		{`return([]byte{97, 98})`, `return([]byte{97,98})`},
+		{`return([]string{ "a", "b", })`, `return([]string{"a","b"})`},
+		{`return([]int{})`, `return([]int{})`},
+		{`return(map[string]int{"a": 1, "b": 2})`, `return(map[string]int{"a":1,"b":2})`},
+		{`return(map[string][]time.Duration{"a": {1s, 2ms}, "b": nil})`, `return(map[string][]time.Duration{"a":{1s,2ms},"b":nil})`},
+		{`return([]error{io.EOF, error("x")})`, `return([]error{io.EOF,error("x")})`},
+		{`return([]any{1, "a", nil})`, `return([]any{1,"a",nil})`},
+		{`return( @entry )`, `return(@entry)`},
+		{`sleep("10ms" .. "2s")`, `sleep("10ms".."2s")`},
+		{`sleep(10..20)`, `sleep(10..20)`},
+		{`sleep(10ms..1.5s)`, `sleep(10ms..1.5s)`},
+		{`sleep( exp( "50ms" ) )`, `sleep(exp("50ms"))`},
+		{`sleep(normal("100ms", 20ms))`, `sleep(normal("100ms",20ms))`},
+	}
+	for _, tt := range tests {
+		ter, err := newTerms("test", tt.desc)
+		require.NoErrorf(t, err, "failed to parse %q", tt.desc)
+		assert.Equalf(t, tt.canonical, ter.String(), "unexpected canonical form of %q", tt.desc)
+		assert.Equalf(t, tt.desc, ter.desc, "terms should keep the original description")
+
+		again, err := newTerms("test", ter.String())
+		require.NoErrorf(t, err, "failed to parse canonical form %q", ter.String())
+		assert.Equalf(t, tt.canonical, again.String(), "canonical form of %q does not round-trip", tt.desc)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		desc     string
+		offset   int
+		expected string
+		found    string
+	}{
+		{``, 0, "an action", "end of input"},
+		{`   `, 3, "an action", "end of input"},
+		{`nope`, 0, "an action", `"nope"`},
+		{`returnx`, 0, "an action", `"returnx"`},
+		{`return(`, 7, "a value", "end of input"},
+		{`return(1`, 8, `")"`, "end of input"},
+		{`return(1) garbage`, 10, `"&", "->" or end of input`, `"garbage"`},
+		{`return(1)&`, 10, "an action", "end of input"},
+		{`return(1)&&print`, 10, "an action", `"&"`},
+		{`return(1)->`, 11, "an action", "end of input"},
+		{`after("5s")->`, 13, "an action", "end of input"},
+		{`->return(1)`, 0, "an action", `"->"`},
+		{`return(1)-print`, 9, "a token", `"-"`},
+		{`return("abc`, 7, "a terminated string", `"\"abc"`},
+		{"return(`abc", 7, "a terminated string", "\"`abc\""},
+		{`return(foo)`, 7, "a value", `"foo"`},
+		{`2.5*return`, 3, `"%"`, `"*"`},
+		{`2return`, 1, `"%", "*", "+" or "/"`, `"return"`},
+		{`0/3return`, 0, "a positive integer", `"0"`},
+		{`4/3return`, 2, "a period of at least 4", `"3"`},
+		{`for(1)return`, 4, "a duration", `"1"`},
+		{`after("1x")return`, 6, "a non-negative duration", `"\"1x\""`},
+		{`error(1)`, 6, "a string or an error", `"1"`},
+		{`return(error(1))`, 13, "a string", `"1"`},
+		{`2*`, 2, "an action", "end of input"},
+		{`-1*return`, 0, "a non-negative number", `"-1"`},
+		{`after(-1)return`, 6, "a non-negative integer", `"-1"`},
+		{`return(1x)`, 7, "a duration", `"1"`},
+		{`return(1 s)`, 9, `")"`, `"s"`},
+		{`return(1e400)`, 7, "a number", `"1e400"`},
+		{`signal("SIGNOPE")`, 7, "a signal name", `"\"SIGNOPE\""`},
+		{`signal`, 6, "a signal name", "end of input"},
+		{`exit("1")`, 5, "an exit code", `"\"1\""`},
+		{`fds(-1)`, 4, "a non-negative integer", `"-1"`},
+		{`fds(2.5)`, 4, "a non-negative integer", `"2.5"`},
+		{`return(1ex)`, 7, "a duration", `"1"`},
+		{`return([]foo{})`, 9, "a type", `"foo"`},
+		{`return([]int{"a"})`, 13, "a value of type int", `"\"a\""`},
+		{`return([]int{1 2})`, 15, `"," or "}"`, `"2"`},
+		{`return([]int{1.5})`, 13, "a value of type int", `"1.5"`},
+		{`return([]uint8{256})`, 15, "a value of type uint8", `"256"`},
+		{`return(map[[]int]int{})`, 11, "a comparable type", `"["`},
+		{`return(map[string]int{"a":1,"a":2})`, 28, "a unique key", `"\"a\""`},
+		{`return(map[string]int{"a"})`, 25, `":"`, `"}"`},
+		{`return([]int(1))`, 12, `"{"`, `"("`},
+		{`return([][]int{{"a"}})`, 16, "a value of type int", `"\"a\""`},
+		{`return([]int{{1}})`, 13, "a value", `"{"`},
+		{`sleep("2s".."1s")`, 12, `a duration of at least "2s"`, `"\"1s\""`},
+		{`sleep(-1..2)`, 6, "a non-negative duration", `"-1"`},
+		{`sleep(1..true)`, 9, `a duration of at least 1`, `"true"`},
+		{`sleep(1..)`, 9, "a value", `")"`},
+		{`sleep(1...2)`, 9, "a token", `"."`},
+		{`return(1..2)`, 7, "a value", `"1"`},
+		{`sleep(exp("x"))`, 10, "a non-negative duration", `"\"x\""`},
+		{`sleep(exp)`, 9, `"("`, `")"`},
+		{`sleep(normal("1s"))`, 17, `","`, `")"`},
+		{`sleep(normal(1,2,3))`, 16, `")"`, `","`},
+	}
+	for _, tt := range tests {
+		_, err := newTerms("test", tt.desc)
+		require.ErrorIsf(t, err, ErrBadParse, "expected %q to fail parsing", tt.desc)
+
+		var perr *ParseError
+		require.ErrorAsf(t, err, &perr, "expected a *ParseError for %q", tt.desc)
+		assert.Equalf(t, tt.desc, perr.Input, "unexpected input for %q", tt.desc)
+		assert.Equalf(t, tt.offset, perr.Offset, "unexpected offset for %q", tt.desc)
+		assert.Equalf(t, tt.expected, perr.Expected, "unexpected expectation for %q", tt.desc)
+		assert.Equalf(t, tt.found, perr.Found, "unexpected token for %q", tt.desc)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		`off`,
+		`2*return("abc")->1*return("def")`,
+		`40.0%return(true)`,
+		`100+1/3return(1)`,
+		`after("5s")for("30s")sleep("10ms")`,
+		`after("5s")->2*return(1)->return(2)`,
+		`return(error("disk full"))->error(io.EOF)`,
+		`return(1) -> panic`,
+		`sleep(1)&return("timeout")->print&panic`,
+		`return(-1.5)->sleep(1h30m)->return(nil)`,
+		`return(map[string][]int{"a":{1,2},"b":nil})`,
+		`return([]byte("abc"))->return([]any{1,"a"})`,
+		`1*return(@corruptEntry)->error(@pathErr)`,
+		`sleep("10ms".."2s")->sleep(exp(50ms))->sleep(normal(100,20))`,
+		"return(`raw`)->error(`disk full`)",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, desc string) {
+		ter, err := newTerms("fuzz", desc)
+		if err != nil {
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected a *ParseError for %q, got %v", desc, err)
+			}
+			if perr.Offset < 0 || perr.Offset > len(desc) {
+				t.Fatalf("offset %d out of range for %q", perr.Offset, desc)
+			}
+			return
+		}
+		canonical := ter.String()
+		again, err := newTerms("fuzz", canonical)
+		if err != nil {
+			t.Fatalf("canonical form %q of %q does not parse: %v", canonical, desc, err)
+		}
+		if again.String() != canonical {
+			t.Fatalf("canonical form %q of %q does not round-trip, got %q", canonical, desc, again.String())
+		}
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sync/atomic"
+)
+
+// seed is the seed from which the random source of every failpoint is derived.
+var seed atomic.Int64
+
+// seedLogged reports whether the seed in use was logged, see logSeed.
+var seedLogged atomic.Bool
+
+// SetSeed sets the seed from which the random source of every failpoint is
+// derived. Terms enabled afterwards draw from a deterministic stream that only
+// depends on the seed and the failpoint name, so a run using probabilistic
+// terms can be replayed by setting the same seed.
+func SetSeed(s int64) {
+	seed.Store(s)
+	seedLogged.Store(false)
+}
+
+// logSeed logs the seed in use, once per seed, so a run can be replayed.
+func logSeed() {
+	if seedLogged.CompareAndSwap(false, true) {
+		getLogger().Info("failpoint: using seed, set GOFAIL_SEED to replay", "seed", Seed())
+	}
+}
+
+// Seed returns the seed in use; see SetSeed.
+func Seed() int64 {
+	return seed.Load()
+}
+
+// newRand returns a random source for the failpoint fpath.
+func newRand(fpath string) *rand.Rand {
+	return deriveRand(seed.Load(), fpath)
+}
+
+// deriveRand returns a random source derived from a seed and the failpoint
+// fpath, so that failpoints sharing a seed draw from different streams.
+func deriveRand(s int64, fpath string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(fpath))
+	return rand.New(rand.NewSource(s ^ int64(h.Sum64())))
+}
@@ -0,0 +1,211 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry holds a set of failpoints and their terms. Failpoints of different
+// registries are independent, even when they have the same name, so parallel
+// tests or libraries embedding gofail can each use a registry of their own.
+// The package-level functions operate on the default registry, which also
+// gets the terms of GOFAIL_FAILPOINTS and is served on GOFAIL_HTTP.
+//
+// A Registry is an http.Handler serving the failpoints like GOFAIL_HTTP does.
+type Registry struct {
+	failpoints map[string]*Failpoint
+	// mu protects the failpoints map, preventing concurrent
+	// accesses during commands such as Enabling and Disabling
+	mu sync.RWMutex
+
+	// envTerms are the terms enabled when a failpoint is registered
+	envTerms map[string]string
+
+	// panicMu (panic mutex) ensures that the action of panic failpoints
+	// and serving of the HTTP requests won't be executed at the same time,
+	// avoiding the possibility that the server runtime panics during processing
+	// requests
+	panicMu sync.Mutex
+}
+
+// defaultRegistry is the registry of the package-level functions.
+var defaultRegistry = NewRegistry()
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		failpoints: make(map[string]*Failpoint),
+		envTerms:   make(map[string]string),
+	}
+}
+
+// DefaultRegistry returns the registry of the package-level functions and
+// of the generated bindings.
+func DefaultRegistry() *Registry { return defaultRegistry }
+
+// NewFailpoint creates and registers a new failpoint with the given name.
+// It panics if the name is already registered.
+func (reg *Registry) NewFailpoint(name string) *Failpoint {
+	return reg.register(name, "")
+}
+
+// NewTypedFailpoint creates and registers a new failpoint with the given name
+// and declared type, see the NewTypedFailpoint function.
+func (reg *Registry) NewTypedFailpoint(name, varType string) *Failpoint {
+	return reg.register(name, varType)
+}
+
+// Enable sets a failpoint to a given failpoint description.
+func (reg *Registry) Enable(name, inTerms string) error {
+	fp := reg.lookup(name)
+	if fp == nil {
+		return ErrNoExist
+	}
+
+	t, err := reg.newTerms(fp, inTerms)
+	if err != nil {
+		getLogger().Error("failed to enable failpoint", "failpoint", name, "terms", inTerms, "error", err)
+		return err
+	}
+
+	fp.SetTerm(t)
+
+	return nil
+}
+
+// Disable stops a failpoint from firing.
+func (reg *Registry) Disable(name string) error {
+	fp := reg.lookup(name)
+	if fp == nil {
+		return ErrNoExist
+	}
+
+	return fp.ClearTerm()
+}
+
+// Status gives the current setting and execution count for the failpoint
+func (reg *Registry) Status(failpath string) (string, int, error) {
+	fp := reg.lookup(failpath)
+	if fp == nil {
+		return "", 0, ErrNoExist
+	}
+
+	return fp.Status()
+}
+
+// Resume releases the goroutines currently paused by the failpoint.
+func (reg *Registry) Resume(failpath string) error {
+	fp := reg.lookup(failpath)
+	if fp == nil {
+		return ErrNoExist
+	}
+
+	return fp.Resume()
+}
+
+// Paused gives the number of goroutines currently paused by the failpoint.
+func (reg *Registry) Paused(failpath string) (int, error) {
+	fp := reg.lookup(failpath)
+	if fp == nil {
+		return 0, ErrNoExist
+	}
+
+	return fp.Paused(), nil
+}
+
+// Type gives the declared type of the failpoint, which is empty if unknown.
+func (reg *Registry) Type(failpath string) (string, error) {
+	fp := reg.lookup(failpath)
+	if fp == nil {
+		return "", ErrNoExist
+	}
+
+	return fp.Type(), nil
+}
+
+// List returns the sorted names of all registered failpoints.
+func (reg *Registry) List() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	ret := make([]string, 0, len(reg.failpoints))
+	for fp := range reg.failpoints {
+		ret = append(ret, fp)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Types returns the declared types of all registered failpoints, by name.
+// The type of a failpoint declared without a type is empty.
+func (reg *Registry) Types() map[string]string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	ret := make(map[string]string, len(reg.failpoints))
+	for name, fp := range reg.failpoints {
+		ret[name] = fp.Type()
+	}
+	return ret
+}
+
+func (reg *Registry) lookup(name string) *Failpoint {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.failpoints[name]
+}
+
+// newTerms parses the terms of a failpoint of the registry, checking that
+// they evaluate to its declared type, and that they do not return nil when
+// the type is unknown.
+func (reg *Registry) newTerms(fp *Failpoint, inTerms string) (*terms, error) {
+	t, err := newTerms(fp.name, inTerms)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.check(fp.typ, fp.varType); err != nil {
+		return nil, err
+	}
+	t.panicMu = &reg.panicMu
+	return t, nil
+}
+
+func (reg *Registry) register(name, varType string) *Failpoint {
+	fp, ok := reg.getOrRegister(name, varType)
+	if !ok {
+		panic(fmt.Sprintf("failpoint name %s is already registered.", name))
+	}
+	return fp
+}
+
+// getOrRegister returns the named failpoint, registering it if needed, and
+// reports whether it was registered by this call.
+func (reg *Registry) getOrRegister(name, varType string) (*Failpoint, bool) {
+	reg.mu.Lock()
+	if fp, ok := reg.failpoints[name]; ok {
+		reg.mu.Unlock()
+		return fp, false
+	}
+
+	fp := &Failpoint{name: name, reg: reg, stress: newStressor(name), varType: varType, typ: lookupType(varType)}
+	reg.failpoints[name] = fp
+	t, ok := reg.envTerms[name]
+	reg.mu.Unlock()
+	if ok {
+		reg.Enable(name, t)
+	}
+	return fp, true
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryIsolation(t *testing.T) {
+	defer clearGlobalVars()
+
+	r1, r2 := NewRegistry(), NewRegistry()
+	fp1 := r1.NewTypedFailpoint("shared", "int")
+	fp2 := r2.NewFailpoint("shared")
+	assert.Panics(t, func() { r1.NewFailpoint("shared") })
+	NewFailpoint("shared")
+
+	require.NoError(t, r1.Enable("shared", "return(1)"))
+	require.NoError(t, r2.Enable("shared", `return("two")`))
+	require.ErrorIs(t, r2.Enable("missing", "return(1)"), ErrNoExist)
+	require.ErrorIs(t, r1.Enable("shared", `return("1")`), ErrBadType)
+
+	v, err := fp1.Acquire()
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+	v, err = fp2.Acquire()
+	require.NoError(t, err)
+	assert.Equal(t, "two", v)
+	_, _, err = Status("shared")
+	assert.ErrorIsf(t, err, ErrDisabled, "the default registry should not be affected")
+
+	require.NoError(t, r1.Disable("shared"))
+	_, err = fp1.Acquire()
+	assert.ErrorIs(t, err, ErrDisabled)
+	status, count, err := r2.Status("shared")
+	require.NoError(t, err)
+	assert.Equal(t, `return("two")`, status)
+	assert.Equal(t, 1, count)
+
+	assert.Equal(t, []string{"shared"}, r1.List())
+	varType, err := r1.Type("shared")
+	require.NoError(t, err)
+	assert.Equal(t, "int", varType)
+}
+
+func TestRegistryParallel(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRegistry()
+			fp := r.NewTypedFailpoint("parallel", "int")
+			require.NoError(t, r.Enable("parallel", fmt.Sprintf("return(%d)", i)))
+			for j := 0; j < 100; j++ {
+				v, err := fp.Acquire()
+				require.NoError(t, err)
+				require.Equal(t, i, v)
+			}
+		})
+	}
+}
+
+func TestRegistryWithFailpoint(t *testing.T) {
+	r1, r2 := NewRegistry(), NewRegistry()
+	fp1 := r1.NewFailpoint("ctxfp")
+	fp2 := r2.NewFailpoint("ctxfp")
+
+	ctx, err := r1.WithFailpoint(context.Background(), "ctxfp", "return(1)")
+	require.NoError(t, err)
+	v, err := fp1.AcquireCtx(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+	_, err = fp2.AcquireCtx(ctx)
+	assert.ErrorIsf(t, err, ErrDisabled, "terms attached for a registry should not fire for another")
+
+	handler := r2.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, err := fp2.AcquireCtx(r.Context())
+		if err != nil {
+			fmt.Fprint(w, "ok")
+			return
+		}
+		fmt.Fprint(w, v)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(Header, `ctxfp=return("injected")`)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "injected", rec.Body.String())
+}
+
+func TestRegistryHTTP(t *testing.T) {
+	defer clearGlobalVars()
+
+	r := NewRegistry()
+	r.NewFailpoint("served")
+	NewFailpoint("other")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/served", strings.NewReader("return(1)")))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/other", strings.NewReader("return(1)")))
+	assert.Equalf(t, http.StatusBadRequest, rec.Code, "failpoints of other registries should not be served")
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "served=return(1)\n", rec.Body.String())
+
+	assert.Same(t, defaultRegistry, DefaultRegistry())
+	_, _, err := Status("served")
+	assert.ErrorIs(t, err, ErrNoExist)
+}
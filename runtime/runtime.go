@@ -16,9 +16,8 @@ package runtime
 
 import (
 	"fmt"
-	"os"
 	"strings"
-	"sync"
+	"time"
 )
 
 var (
@@ -26,38 +25,14 @@ var (
 	ErrNoExist = fmt.Errorf("failpoint: failpoint does not exist")
 	// ErrDisabled indicates that the failpoint is currently disabled.
 	ErrDisabled = fmt.Errorf("failpoint: failpoint is disabled")
-
-	failpoints map[string]*Failpoint
-	// failpointsMu protects the failpoints map, preventing concurrent
-	// accesses during commands such as Enabling and Disabling
-	failpointsMu sync.RWMutex
-
-	envTerms map[string]string
-
-	// panicMu (panic mutex) ensures that the action of panic failpoints
-	// and serving of the HTTP requests won't be executed at the same time,
-	// avoiding the possibility that the server runtime panics during processing
-	// requests
-	panicMu sync.Mutex
+	// ErrBadType indicates that the terms evaluate to a value which does not
+	// convert to the declared type of the failpoint.
+	ErrBadType = fmt.Errorf("failpoint: value of unexpected type")
 )
 
 func init() {
-	failpoints = make(map[string]*Failpoint)
-	envTerms = make(map[string]string)
-	if s := os.Getenv("GOFAIL_FAILPOINTS"); len(s) > 0 {
-		fpMap, err := parseFailpoints(s)
-		if err != nil {
-			fmt.Printf("fail to parse failpoint: %v\n", err)
-			os.Exit(1)
-		}
-		envTerms = fpMap
-	}
-	if s := os.Getenv("GOFAIL_HTTP"); len(s) > 0 {
-		if err := serve(s); err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
-	}
+	SetSeed(time.Now().UnixNano())
+	readEnv()
 }
 
 func parseFailpoints(fps string) (map[string]string, error) {
@@ -80,75 +55,40 @@ func parseFailpoints(fps string) (map[string]string, error) {
 
 // Enable sets a failpoint to a given failpoint description.
 func Enable(name, inTerms string) error {
-	failpointsMu.RLock()
-	fp := failpoints[name]
-	failpointsMu.RUnlock()
-	if fp == nil {
-		return ErrNoExist
-	}
-
-	t, err := newTerms(name, inTerms)
-	if err != nil {
-		fmt.Printf("failed to enable \"%s=%s\" (%v)\n", name, inTerms, err)
-		return err
-	}
-
-	fp.SetTerm(t)
-
-	return nil
+	return defaultRegistry.Enable(name, inTerms)
 }
 
 // Disable stops a failpoint from firing.
 func Disable(name string) error {
-	failpointsMu.RLock()
-	fp := failpoints[name]
-	failpointsMu.RUnlock()
-	if fp == nil {
-		return ErrNoExist
-	}
-
-	return fp.ClearTerm()
+	return defaultRegistry.Disable(name)
 }
 
 // Status gives the current setting and execution count for the failpoint
 func Status(failpath string) (string, int, error) {
-	failpointsMu.RLock()
-	fp := failpoints[failpath]
-	failpointsMu.RUnlock()
-	if fp == nil {
-		return "", 0, ErrNoExist
-	}
+	return defaultRegistry.Status(failpath)
+}
 
-	return fp.Status()
+// Resume releases the goroutines currently paused by the failpoint.
+func Resume(failpath string) error {
+	return defaultRegistry.Resume(failpath)
 }
 
-// List returns a list of all registered failpoints.
-func List() []string {
-	failpointsMu.Lock()
-	defer failpointsMu.Unlock()
-	return list()
+// Paused gives the number of goroutines currently paused by the failpoint.
+func Paused(failpath string) (int, error) {
+	return defaultRegistry.Paused(failpath)
 }
 
-func list() []string {
-	ret := make([]string, 0, len(failpoints))
-	for fp := range failpoints {
-		ret = append(ret, fp)
-	}
-	return ret
+// Type gives the declared type of the failpoint, which is empty if unknown.
+func Type(failpath string) (string, error) {
+	return defaultRegistry.Type(failpath)
 }
 
-func register(name string) *Failpoint {
-	failpointsMu.Lock()
-	if _, ok := failpoints[name]; ok {
-		failpointsMu.Unlock()
-		panic(fmt.Sprintf("failpoint name %s is already registered.", name))
-	}
+// Types returns the declared types of all registered failpoints, by name.
+func Types() map[string]string {
+	return defaultRegistry.Types()
+}
 
-	fp := &Failpoint{}
-	failpoints[name] = fp
-	failpointsMu.Unlock()
-	if t, ok := envTerms[name]; ok {
-		Enable(name, t)
-	}
-	return fp
+// List returns a list of all registered failpoints.
+func List() []string {
+	return defaultRegistry.List()
 }
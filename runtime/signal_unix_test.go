@@ -0,0 +1,57 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package runtime
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTermsSignal(t *testing.T) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGHUP)
+	defer signal.Stop(ch)
+
+	tests := []struct {
+		desc string
+		wsig os.Signal
+	}{
+		{`signal("SIGUSR1")`, syscall.SIGUSR1},
+		{`signal("HUP")`, syscall.SIGHUP},
+	}
+	for _, tt := range tests {
+		ter, err := newTerms("test", tt.desc)
+		require.NoError(t, err)
+		assert.Nil(t, ter.eval())
+		select {
+		case sig := <-ch:
+			assert.Equalf(t, tt.wsig, sig, "%s: unexpected signal", tt.desc)
+		case <-time.After(time.Second):
+			t.Fatalf("%s: signal was not raised", tt.desc)
+		}
+	}
+
+	// unknown signals are rejected
+	_, err := newTerms("test", `signal("SIGNOPE")`)
+	require.ErrorIs(t, err, ErrBadParse)
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"math/rand"
+	goruntime "runtime"
+	"sync"
+	"time"
+)
+
+var (
+	// stressSeed is the seed of the stress mode, see GOFAIL_STRESS.
+	stressSeed int64
+	// stressEnabled enables the stress mode for failpoints registered afterwards.
+	stressEnabled bool
+)
+
+// stressor perturbs the scheduling of goroutines reaching a failpoint
+// without terms, turning it into a point shaking out races.
+type stressor struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// newStressor returns the stressor of the failpoint fpath, or nil if the
+// stress mode is disabled.
+func newStressor(fpath string) *stressor {
+	if !stressEnabled {
+		return nil
+	}
+	return &stressor{rnd: deriveRand(stressSeed, fpath)}
+}
+
+// perturb does nothing, yields the processor, or sleeps a few microseconds.
+func (s *stressor) perturb() {
+	s.mu.Lock()
+	n := s.rnd.Intn(8)
+	s.mu.Unlock()
+	switch {
+	case n < 4:
+	case n < 6:
+		goruntime.Gosched()
+	default:
+		time.Sleep(time.Duration(n) * time.Microsecond)
+	}
+}
@@ -15,20 +15,22 @@
 package runtime
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"math"
 	"math/rand"
 	"os"
 	"os/exec"
+	"reflect"
+	goruntime "runtime"
+	"runtime/pprof"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-var (
-	// ErrBadParse indicates that the failpoint term string could not be parsed.
-	ErrBadParse = fmt.Errorf("failpoint: could not parse terms")
-)
-
 // terms encodes the state for a failpoint term string (see fail(9) for examples)
 // <fp> :: <term> ( "->" <term> )*
 type terms struct {
@@ -43,290 +45,504 @@ type terms struct {
 	mu sync.Mutex
 	// tracks executions count of terms that are actually evaluated
 	counter int
+	// rnd is the random source of the terms, derived from the seed and fpath
+	rnd *rand.Rand
+	// resume is closed to release the goroutines paused by the terms
+	resume chan struct{}
+	// paused is the number of goroutines currently paused by the terms
+	paused int
+
+	// done is closed once the terms are cleared from the failpoint
+	done chan struct{}
+	// held are the resources retained by actions until the terms are stopped
+	held []io.Closer
+
+	// panicMu is the panic mutex of the registry of the terms, if any
+	panicMu *sync.Mutex
 }
 
 // term is an executable unit of the failpoint terms chain
 type term struct {
+	// desc is the canonical form of the term
 	desc string
 
 	mods mod
-	act  actFunc
-	val  interface{}
+	acts []action
 
 	parent *terms
 }
 
+// action is one of the actions of a term, along with its value.
+type action struct {
+	name string
+	fn   actFunc
+	val  interface{}
+}
+
+// mod gates the evaluations of a term. The state of a mod advances on every
+// evaluation reaching the term, except for the state of a modFire, which
+// advances only when the term is executed.
 type mod interface {
-	allow() bool
+	allow(t *terms) bool
+}
+
+// modFire is implemented by mods counting the executions of the term.
+type modFire interface {
+	fire()
 }
 
 type modCount struct{ c int }
 
-func (mc *modCount) allow() bool {
-	if mc.c > 0 {
-		mc.c--
-		return true
+func (mc *modCount) allow(_ *terms) bool { return mc.c > 0 }
+
+func (mc *modCount) fire() { mc.c-- }
+
+// modSkip lets the first n evaluations pass without triggering the term.
+type modSkip struct{ n int }
+
+func (ms *modSkip) allow(_ *terms) bool {
+	if ms.n > 0 {
+		ms.n--
+		return false
 	}
-	return false
+	return true
+}
+
+func (ms *modSkip) status() string {
+	if ms.n > 0 {
+		return fmt.Sprintf("skip %d more", ms.n)
+	}
+	return ""
+}
+
+// modPeriod triggers the term on the last k of every n evaluations.
+type modPeriod struct {
+	k, n int
+	// i is the position of the current evaluation within the period
+	i int
+}
+
+func (mp *modPeriod) allow(_ *terms) bool {
+	mp.i = mp.i%mp.n + 1
+	return mp.i > mp.n-mp.k
+}
+
+// modDelay holds the term off until the given time.
+type modDelay struct{ t time.Time }
+
+func (md *modDelay) allow(_ *terms) bool { return !time.Now().Before(md.t) }
+
+func (md *modDelay) status() string {
+	if d := time.Until(md.t); d > 0 {
+		return fmt.Sprintf("armed in %v", d.Round(time.Millisecond))
+	}
+	return ""
+}
+
+// modExpire disables the term once the given time has passed.
+type modExpire struct{ t time.Time }
+
+func (me *modExpire) allow(_ *terms) bool { return time.Now().Before(me.t) }
+
+func (me *modExpire) status() string {
+	if d := time.Until(me.t); d > 0 {
+		return fmt.Sprintf("expires in %v", d.Round(time.Millisecond))
+	}
+	return "expired"
 }
 
 type modProb struct{ p float64 }
 
-func (mp *modProb) allow() bool { return rand.Float64() <= mp.p }
+func (mp *modProb) allow(t *terms) bool { return t.rnd.Float64() <= mp.p }
 
 type modList struct{ l []mod }
 
-func (ml *modList) allow() bool {
+// allow checks every mod, so that each of them sees all the evaluations
+// whichever mod rejects them.
+func (ml *modList) allow(t *terms) bool {
+	ok := true
 	for _, m := range ml.l {
-		if !m.allow() {
-			return false
+		if !m.allow(t) {
+			ok = false
 		}
 	}
-	return true
+	return ok
+}
+
+func (ml *modList) fire() {
+	for _, m := range ml.l {
+		if mf, ok := m.(modFire); ok {
+			mf.fire()
+		}
+	}
+}
+
+func (ml *modList) status() string {
+	var ss []string
+	for _, m := range ml.l {
+		if ms, ok := m.(modStatus); ok {
+			if s := ms.status(); len(s) != 0 {
+				ss = append(ss, s)
+			}
+		}
+	}
+	return strings.Join(ss, ", ")
+}
+
+// modStatus is implemented by mods that have state worth reporting by Status.
+type modStatus interface {
+	status() string
 }
 
 func newTerms(fpath, desc string) (*terms, error) {
-	chain := parse(desc)
-	if len(chain) == 0 {
-		return nil, ErrBadParse
+	chain, err := parse(desc)
+	if err != nil {
+		return nil, err
+	}
+	t := &terms{
+		chain:  chain,
+		desc:   desc,
+		fpath:  fpath,
+		rnd:    newRand(fpath),
+		resume: make(chan struct{}),
+		done:   make(chan struct{}),
 	}
-	t := &terms{chain: chain, desc: desc, fpath: fpath}
 	for _, c := range chain {
 		c.parent = t
 	}
+	if t.random() {
+		// print the seed so a run hitting probabilistic terms can be replayed
+		logSeed()
+	}
 	return t, nil
 }
 
-func (t *terms) String() string { return t.desc }
-
-func (t *terms) eval() interface{} {
-	t.mu.Lock()
-	defer t.mu.Unlock()
+// random reports whether the terms draw from their random source.
+func (t *terms) random() bool {
 	for _, term := range t.chain {
-		if term.mods.allow() {
-			t.counter++
-			return term.do()
-		}
-	}
-	return nil
-}
-
-// split terms from a -> b -> ... into [a, b, ...]
-func parse(desc string) (chain []*term) {
-	origDesc := desc
-	for len(desc) != 0 {
-		t := parseTerm(desc)
-		if t == nil {
-			fmt.Printf("failed to parse %q past %q\n", origDesc, desc)
-			return nil
+		if ml, ok := term.mods.(*modList); ok {
+			for _, m := range ml.l {
+				if _, ok := m.(*modProb); ok {
+					return true
+				}
+			}
 		}
-		desc = desc[len(t.desc):]
-		chain = append(chain, t)
-		if len(desc) >= 2 {
-			if !strings.HasPrefix(desc, "->") {
-				fmt.Printf("failed to parse %q past %q, expected \"->\"\n", origDesc, desc)
-				return nil
+		for _, a := range term.acts {
+			switch a.val.(type) {
+			case latency:
+				return true
+			case time.Duration, string:
+				if a.name == "yield" {
+					return true
+				}
 			}
-			desc = desc[2:]
 		}
 	}
-	return chain
+	return false
 }
 
-// <term> :: <mod> <act> [ "(" <val> ")" ]
-func parseTerm(desc string) *term {
-	t := &term{}
-	modStr, mods := parseMod(desc)
-	t.mods = &modList{mods}
-	actStr, act := parseAct(desc[len(modStr):])
-	t.act = act
-	valStr, val := parseVal(desc[len(modStr)+len(actStr):])
-	t.val = val
-	t.desc = desc[:len(modStr)+len(actStr)+len(valStr)]
-	if len(t.desc) == 0 {
-		return nil
+// String returns the canonical form of the terms, which parses back to
+// equivalent terms.
+func (t *terms) String() string {
+	descs := make([]string, len(t.chain))
+	for i, c := range t.chain {
+		descs[i] = c.desc
 	}
-	return t
+	return strings.Join(descs, "->")
 }
 
-// <mod> :: ((<float> "%")|(<int> "*" ))*
-func parseMod(desc string) (ret string, mods []mod) {
-	for {
-		s, v := parseIntFloat(desc)
-		if len(s) == 0 {
-			break
-		}
-		if len(s) == len(desc) {
-			return "", nil
-		}
-		switch v := v.(type) {
-		case float64:
-			if desc[len(s)] != '%' {
-				return "", nil
+// check returns an error if the terms evaluate to a value which does not
+// convert to typ, or to nil when typ is nil as nil has no value of its own.
+// Only the values known before execution are checked, that is the ones of
+// return and error actions not naming a registered value.
+func (t *terms) check(typ reflect.Type, varType string) error {
+	for _, term := range t.chain {
+		for _, a := range term.acts {
+			if _, ok := a.val.(valueRef); ok {
+				continue
 			}
-			ret = ret + desc[:len(s)+1]
-			mods = append(mods, &modProb{v / 100.0})
-			desc = desc[len(s)+1:]
-		case int:
-			if desc[len(s)] != '*' {
-				return "", nil
+			var v interface{}
+			switch a.name {
+			case "return":
+				v = a.val
+			case "error":
+				v = actError(context.Background(), term, a.val, 0)
+			default:
+				continue
+			}
+			if typ == nil {
+				// nil converts to a value of a known type only
+				if _, ok := v.(nilValue); ok {
+					return fmt.Errorf("%w: term %q of failpoint %q returns nil, but the type of the failpoint is unknown",
+						ErrBadType, term.desc, t.fpath)
+				}
+				continue
+			}
+			if _, ok := convertValue(v, typ); !ok {
+				return fmt.Errorf("%w: term %q of failpoint %q does not evaluate to %s",
+					ErrBadType, term.desc, t.fpath, varType)
 			}
-			ret = ret + desc[:len(s)+1]
-			mods = append(mods, &modCount{v})
-			desc = desc[len(s)+1:]
-		default:
-			panic("???")
 		}
 	}
-	return ret, mods
+	return nil
 }
 
-// parseIntFloat parses an int or float from a string and returns the string
-// it parsed it from (unlike scanf).
-func parseIntFloat(desc string) (string, interface{}) {
-	// parse for ints
-	i := 0
-	for i < len(desc) {
-		if desc[i] < '0' || desc[i] > '9' {
-			break
+// status returns the term description annotated with the state of its
+// mods, along with the execution counter.
+func (t *terms) status() (string, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var ss []string
+	for _, term := range t.chain {
+		if ms, ok := term.mods.(modStatus); ok {
+			if s := ms.status(); len(s) != 0 {
+				ss = append(ss, term.desc+": "+s)
+			}
 		}
-		i++
 	}
-	if i == 0 {
-		return "", nil
-	}
-
-	intVal := int(0)
-	_, err := fmt.Sscanf(desc[:i], "%d", &intVal)
-	if err != nil {
-		return "", nil
-	}
-	if len(desc) == i {
-		return desc[:i], intVal
-	}
-	if desc[i] != '.' {
-		return desc[:i], intVal
+	if len(ss) == 0 {
+		return t.desc, t.counter
 	}
+	return t.desc + " [" + strings.Join(ss, "; ") + "]", t.counter
+}
 
-	// parse for floats
-	i++
-	if i == len(desc) {
-		return desc[:i], float64(intVal)
-	}
+// eval picks the first term of the chain allowed by its mods and executes it.
+func (t *terms) eval() interface{} {
+	return t.evalCtx(context.Background())
+}
 
-	j := i
-	for i < len(desc) {
-		if desc[i] < '0' || desc[i] > '9' {
+// evalCtx is like eval, but blocking actions return once ctx is done.
+// The action runs without holding the terms lock, so blocking actions don't
+// hold off other goroutines evaluating the same terms.
+func (t *terms) evalCtx(ctx context.Context) interface{} {
+	t.mu.Lock()
+	var picked *term
+	for _, term := range t.chain {
+		if term.mods.allow(t) {
+			if mf, ok := term.mods.(modFire); ok {
+				mf.fire()
+			}
+			t.counter++
+			picked = term
 			break
 		}
-		i++
 	}
-	if j == i {
-		return desc[:i], float64(intVal)
+	count := t.counter
+	t.mu.Unlock()
+	if picked == nil {
+		return nil
 	}
+	return picked.do(ctx, count)
+}
 
-	f := float64(0)
-	if _, err = fmt.Sscanf(desc[:i], "%f", &f); err != nil {
-		return "", nil
-	}
-	return desc[:i], f
+// randInt63n draws a number in [0, n) from the random source of the terms.
+func (t *terms) randInt63n(n int64) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rnd.Int63n(n)
 }
 
-// parseAct parses an action
-// <act> :: "off" | "return" | "sleep" | "panic" | "break" | "print"
-func parseAct(desc string) (string, actFunc) {
-	for k, v := range actMap {
-		if strings.HasPrefix(desc, k) {
-			return k, v
-		}
-	}
-	return "", nil
+// randFloat64 draws a number using draw on the random source of the terms.
+func (t *terms) randFloat64(draw func(*rand.Rand) float64) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return draw(t.rnd)
 }
 
-// <val> :: <int> | <string> | <bool> | <nothing>
-func parseVal(desc string) (string, interface{}) {
-	// return => struct{}
-	if len(desc) == 0 {
-		return "", struct{}{}
-	}
-	// malformed
-	if len(desc) == 1 || desc[0] != '(' {
-		return "", nil
-	}
-	// return() => struct{}
-	if desc[1] == ')' {
-		return "()", struct{}{}
-	}
-	// return("s") => string
-	s := ""
-	n, err := fmt.Sscanf(desc[1:], "%q", &s)
-	if n == 1 && err == nil {
-		return desc[:len(s)+4], s
+// resumePaused releases the goroutines currently paused by the terms.
+func (t *terms) resumePaused() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	close(t.resume)
+	t.resume = make(chan struct{})
+}
+
+// pausedCount returns the number of goroutines currently paused by the terms.
+func (t *terms) pausedCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.paused
+}
+
+// stop marks the terms as cleared or replaced on the failpoint, releasing
+// the goroutines blocked in its actions, such as sleep and pause.
+func (t *terms) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+	default:
+		close(t.done)
 	}
-	// return(1) => int
-	v := 0
-	n, err = fmt.Sscanf(desc[1:], "%d", &v)
-	if n == 1 && err == nil {
-		return desc[:len(fmt.Sprintf("%d", v))+2], v
+	for _, c := range t.held {
+		c.Close()
 	}
-	// return(true) => bool
-	b := false
-	n, err = fmt.Sscanf(desc[1:], "%t", &b)
-	if n == 1 && err == nil {
-		return desc[:len(fmt.Sprintf("%t", b))+2], b
+	t.held = nil
+}
+
+// hold retains the resource until the terms are stopped. If they already
+// are, the resource is released right away.
+func (t *terms) hold(c io.Closer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		c.Close()
+	default:
+		t.held = append(t.held, c)
 	}
-	// unknown type; malformed input?
-	return "", nil
 }
 
-type actFunc func(*term) interface{}
+// actFunc executes an action of a term; count is the execution count of
+// the terms including this execution. Blocking actions return once ctx is done.
+type actFunc func(ctx context.Context, t *term, val interface{}, count int) interface{}
 
 var actMap = map[string]actFunc{
 	"off":    actOff,
 	"return": actReturn,
+	"error":  actError,
 	"sleep":  actSleep,
+	"pause":  actPause,
 	"panic":  actPanic,
 	"break":  actBreak,
 	"print":  actPrint,
+	"log":    actLog,
+	"exit":   actExit,
+	"kill":   actKill,
+	"signal": actSignal,
+
+	"yield": actYield,
+	"alloc": actAlloc,
+	"burn":  actBurn,
+	"fds":   actFds,
+
+	"stack":            actStack,
+	"heapprofile":      actHeapProfile,
+	"goroutineprofile": actGoroutineProfile,
 }
 
-func (t *term) do() interface{} { return t.act(t) }
+// do executes the actions of the term in order, and returns the value of the
+// last action producing one. It returns nil once the terms are stopped or ctx
+// is done during the execution, as the blocking actions are then cut short.
+func (t *term) do(ctx context.Context, count int) interface{} {
+	var ret interface{}
+	stopped := t.parent.stopped(ctx)
+	for _, a := range t.acts {
+		val := a.val
+		if ref, ok := val.(valueRef); ok {
+			// named values are resolved on every execution
+			if val, ok = lookupValue(string(ref)); !ok {
+				getLogger().Warn("failpoint: unregistered value", "failpoint", t.parent.fpath, "value", ref.String())
+				continue
+			}
+		}
+		if v := a.fn(ctx, t, val, count); v != nil {
+			ret = v
+		}
+		if !stopped && t.parent.stopped(ctx) {
+			return nil
+		}
+	}
+	return ret
+}
 
-func actOff(_ *term) interface{} { return nil }
+func actOff(_ context.Context, _ *term, _ interface{}, _ int) interface{} { return nil }
 
-func actReturn(t *term) interface{} { return t.val }
+func actReturn(_ context.Context, _ *term, val interface{}, _ int) interface{} { return val }
+
+func actError(_ context.Context, t *term, val interface{}, _ int) interface{} {
+	switch v := val.(type) {
+	case error:
+		return v
+	case struct{}:
+		return fmt.Errorf("failpoint error: %s", t.parent.fpath)
+	default:
+		// a named value which is not an error
+		getLogger().Warn("failpoint: ignoring error", "failpoint", t.parent.fpath, "value", v, "type", fmt.Sprintf("%T", v))
+		return nil
+	}
+}
 
-func actSleep(t *term) interface{} {
+func actSleep(ctx context.Context, t *term, val interface{}, _ int) interface{} {
 	var dur time.Duration
-	switch v := t.val.(type) {
+	switch v := val.(type) {
 	case int:
 		dur = time.Duration(v) * time.Millisecond
+	case time.Duration:
+		dur = v
+	case latency:
+		dur = v.sample(t.parent)
 	case string:
 		vDur, err := time.ParseDuration(v)
 		if err != nil {
-			fmt.Printf("failpoint: could not parse sleep(%v) on %s\n", v, t.parent.fpath)
+			getLogger().Warn("failpoint: could not parse sleep", "failpoint", t.parent.fpath, "value", v)
 			return nil
 		}
 		dur = vDur
 	default:
-		fmt.Printf("failpoint: ignoring sleep(%v) on %s\n", v, t.parent.fpath)
+		getLogger().Warn("failpoint: ignoring sleep", "failpoint", t.parent.fpath, "value", v)
 		return nil
 	}
-	time.Sleep(dur)
+	t.parent.sleep(ctx, dur)
+	return nil
+}
+
+// sleep waits for the given duration, or until the terms are stopped or
+// ctx is done.
+func (t *terms) sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-t.done:
+	case <-ctx.Done():
+	}
+}
+
+// stopped reports whether the terms are stopped or ctx is done.
+func (t *terms) stopped(ctx context.Context) bool {
+	select {
+	case <-t.done:
+		return true
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+func actPause(ctx context.Context, t *term, val interface{}, _ int) interface{} {
+	p := t.parent
+	p.mu.Lock()
+	p.paused++
+	resume := p.resume
+	p.mu.Unlock()
+
+	select {
+	case <-resume:
+	case <-p.done:
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	p.paused--
+	p.mu.Unlock()
 	return nil
 }
 
-func actPanic(t *term) interface{} {
-	panicMu.Lock()
-	defer panicMu.Unlock()
+func actPanic(_ context.Context, t *term, val interface{}, _ int) interface{} {
+	if mu := t.parent.panicMu; mu != nil {
+		mu.Lock()
+		defer mu.Unlock()
+	}
 
-	if t.val != nil {
-		panic(fmt.Sprintf("failpoint panic: %v", t.val))
+	if val != nil {
+		panic(fmt.Sprintf("failpoint panic: %v", val))
 	}
 	panic("failpoint panic: " + t.parent.fpath)
 }
 
-func actBreak(_ *term) interface{} {
+func actBreak(_ context.Context, _ *term, _ interface{}, _ int) interface{} {
 	p, perr := exec.LookPath(os.Args[0])
 	if perr != nil {
 		panic(perr)
@@ -350,7 +566,278 @@ func actBreak(_ *term) interface{} {
 	return nil
 }
 
-func actPrint(t *term) interface{} {
-	fmt.Println("failpoint print:", t.parent.fpath)
+func actPrint(_ context.Context, t *term, val interface{}, _ int) interface{} {
+	getLogger().Info("failpoint print", "failpoint", t.parent.fpath)
+	return nil
+}
+
+func actExit(_ context.Context, t *term, val interface{}, _ int) interface{} {
+	code := 1
+	switch v := val.(type) {
+	case int:
+		code = v
+	case struct{}:
+	default:
+		getLogger().Warn("failpoint: ignoring exit", "failpoint", t.parent.fpath, "value", v)
+		return nil
+	}
+	// exit right away, without running deferred functions
+	os.Exit(code)
+	return nil
+}
+
+func actKill(_ context.Context, _ *term, _ interface{}, _ int) interface{} {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		panic(err)
+	}
+	// SIGKILL can't be handled, so nothing gets flushed
+	if err := p.Kill(); err != nil {
+		panic(err)
+	}
+	// don't let the caller make progress while the signal is delivered
+	select {}
+}
+
+// lookupSignal returns the signal of the given name, with or without the
+// "SIG" prefix.
+func lookupSignal(name string) (os.Signal, bool) {
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	sig, ok := signals[name]
+	return sig, ok
+}
+
+func actSignal(_ context.Context, t *term, val interface{}, _ int) interface{} {
+	name, ok := val.(string)
+	if !ok {
+		getLogger().Warn("failpoint: ignoring signal", "failpoint", t.parent.fpath, "value", val)
+		return nil
+	}
+	sig, ok := lookupSignal(name)
+	if !ok {
+		getLogger().Warn("failpoint: unknown signal", "failpoint", t.parent.fpath, "value", val)
+		return nil
+	}
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(sig)
+	}
+	if err != nil {
+		getLogger().Error("failpoint: failed to raise signal", "failpoint", t.parent.fpath, "value", val, "error", err)
+	}
+	return nil
+}
+
+func actStack(_ context.Context, t *term, val interface{}, count int) interface{} {
+	buf := make([]byte, 64*1024)
+	for {
+		n := goruntime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var w io.Writer = os.Stderr
+	switch v := val.(type) {
+	case string:
+		f, err := os.OpenFile(v, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			getLogger().Error("failpoint: could not open stack", "failpoint", t.parent.fpath, "value", v, "error", err)
+			return nil
+		}
+		defer f.Close()
+		w = f
+	case struct{}:
+	default:
+		getLogger().Warn("failpoint: ignoring stack", "failpoint", t.parent.fpath, "value", v)
+		return nil
+	}
+	fmt.Fprintf(w, "failpoint stack: %s (execution %d)\n%s\n", t.parent.fpath, count, buf)
+	return nil
+}
+
+func actHeapProfile(_ context.Context, t *term, val interface{}, _ int) interface{} {
+	return writeProfile(t, val, "heap")
+}
+
+func actGoroutineProfile(_ context.Context, t *term, val interface{}, _ int) interface{} {
+	return writeProfile(t, val, "goroutine")
+}
+
+// writeProfile writes the named pprof profile to the path given as term value.
+func writeProfile(t *term, val interface{}, name string) interface{} {
+	path, ok := val.(string)
+	if !ok {
+		getLogger().Warn("failpoint: ignoring "+name+"profile", "failpoint", t.parent.fpath, "value", val)
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		getLogger().Error("failpoint: could not create "+name+"profile", "failpoint", t.parent.fpath, "value", path, "error", err)
+		return nil
+	}
+	defer f.Close()
+	if err := pprof.Lookup(name).WriteTo(f, 0); err != nil {
+		getLogger().Error("failpoint: could not write "+name+"profile", "failpoint", t.parent.fpath, "value", path, "error", err)
+	}
+	return nil
+}
+
+// memBlock is memory retained by the alloc action.
+type memBlock []byte
+
+func (memBlock) Close() error { return nil }
+
+var sizeUnits = []struct {
+	suffix string
+	size   int
+}{
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"KB", 1 << 10},
+	{"MB", 1 << 20},
+	{"GB", 1 << 30},
+	{"B", 1},
+}
+
+// parseSize parses a size such as "512MB"; units are powers of 1024.
+// Negative sizes and sizes overflowing an int are rejected.
+func parseSize(s string) (int, error) {
+	num, unit := s, 1
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			num, unit = strings.TrimSuffix(s, u.suffix), u.size
+			break
+		}
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(num))
+	if err != nil || n < 0 || n > math.MaxInt/unit {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * unit, nil
+}
+
+// makeBlock allocates a memory block, or returns false if the size is
+// beyond what can be allocated.
+func makeBlock(size int) (b memBlock, ok bool) {
+	defer func() {
+		if recover() != nil {
+			b, ok = nil, false
+		}
+	}()
+	return make(memBlock, size), true
+}
+
+func actAlloc(_ context.Context, t *term, val interface{}, _ int) interface{} {
+	var size int
+	switch v := val.(type) {
+	case int:
+		if v < 0 {
+			getLogger().Warn("failpoint: ignoring negative alloc", "failpoint", t.parent.fpath, "value", v)
+			return nil
+		}
+		size = v
+	case string:
+		n, err := parseSize(v)
+		if err != nil {
+			getLogger().Warn("failpoint: could not parse alloc", "failpoint", t.parent.fpath, "value", v)
+			return nil
+		}
+		size = n
+	default:
+		getLogger().Warn("failpoint: ignoring alloc", "failpoint", t.parent.fpath, "value", v)
+		return nil
+	}
+	b, ok := makeBlock(size)
+	if !ok {
+		getLogger().Warn("failpoint: alloc too large", "failpoint", t.parent.fpath, "value", val)
+		return nil
+	}
+	// touch every page so the memory is actually resident
+	for i := 0; i < len(b); i += os.Getpagesize() {
+		b[i] = 1
+	}
+	t.parent.hold(b)
+	return nil
+}
+
+func actBurn(ctx context.Context, t *term, val interface{}, _ int) interface{} {
+	var dur time.Duration
+	switch v := val.(type) {
+	case int:
+		dur = time.Duration(v) * time.Millisecond
+	case time.Duration:
+		dur = v
+	case string:
+		vDur, err := time.ParseDuration(v)
+		if err != nil {
+			getLogger().Warn("failpoint: could not parse burn", "failpoint", t.parent.fpath, "value", v)
+			return nil
+		}
+		dur = vDur
+	default:
+		getLogger().Warn("failpoint: ignoring burn", "failpoint", t.parent.fpath, "value", v)
+		return nil
+	}
+	// spin on the CPU instead of sleeping, until done or stopped
+	for deadline := time.Now().Add(dur); time.Now().Before(deadline); {
+		select {
+		case <-t.parent.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+	}
+	return nil
+}
+
+func actFds(_ context.Context, t *term, val interface{}, _ int) interface{} {
+	n, ok := val.(int)
+	if !ok {
+		getLogger().Warn("failpoint: ignoring fds", "failpoint", t.parent.fpath, "value", val)
+		return nil
+	}
+	for i := 0; i < n; i++ {
+		f, err := os.Open(os.DevNull)
+		if err != nil {
+			getLogger().Warn("failpoint: could not open all fds", "failpoint", t.parent.fpath, "value", n, "opened", i, "error", err)
+			return nil
+		}
+		t.parent.hold(f)
+	}
+	return nil
+}
+
+func actYield(ctx context.Context, t *term, val interface{}, count int) interface{} {
+	switch v := val.(type) {
+	case struct{}:
+		goruntime.Gosched()
+	case int:
+		for i := 0; i < v; i++ {
+			goruntime.Gosched()
+		}
+	case time.Duration:
+		// sleep up to the given duration
+		if v <= 0 {
+			getLogger().Warn("failpoint: ignoring yield", "failpoint", t.parent.fpath, "value", v)
+			return nil
+		}
+		t.parent.sleep(ctx, time.Duration(t.parent.randInt63n(int64(v))))
+	case string:
+		vDur, err := time.ParseDuration(v)
+		if err != nil || vDur <= 0 {
+			getLogger().Warn("failpoint: could not parse yield", "failpoint", t.parent.fpath, "value", v)
+			return nil
+		}
+		return actYield(ctx, t, vDur, count)
+	default:
+		getLogger().Warn("failpoint: ignoring yield", "failpoint", t.parent.fpath, "value", v)
+	}
 	return nil
 }
@@ -15,8 +15,19 @@
 package runtime
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -31,6 +42,22 @@ func TestTermsString(t *testing.T) {
 		{`2*return("abc")`, []string{"abc", "abc", ""}},
 		{`2*return("abc")->1*return("def")`, []string{"abc", "abc", "def", ""}},
 		{`1*return("abc")->return("def")`, []string{"abc", "def", "def"}},
+		{`2+return("abc")`, []string{"", "", "abc", "abc"}},
+		{`2+1*return("abc")->return("def")`, []string{"def", "def", "abc", "def"}},
+		{`1+2*return("abc")`, []string{"", "abc", "abc", ""}},
+		{`1/3return("abc")`, []string{"", "", "abc", "", "", "abc", "", ""}},
+		{`2/3return("abc")`, []string{"", "abc", "abc", "", "abc", "abc"}},
+		{`1/2return("abc")->return("def")`, []string{"def", "abc", "def", "abc"}},
+		{`2+1/2return("abc")`, []string{"", "", "", "abc", "", "abc"}},
+		{`2*1/2return("abc")`, []string{"", "abc", "", "abc", "", ""}},
+		{`2*3+return("abc")`, []string{"", "", "", "abc", "abc", ""}},
+		{`3+2*return("abc")`, []string{"", "", "", "abc", "abc", ""}},
+		{`1*after(1)return("abc")->return("def")`, []string{"def", "abc", "def"}},
+		{`after(2)return("abc")`, []string{"", "", "abc", "abc"}},
+		{`after(1)1*return("abc")->return("def")`, []string{"def", "abc", "def"}},
+		{`for("1h")return("abc")`, []string{"abc", "abc"}},
+		{`after("1h")return("abc")->return("def")`, []string{"def", "def"}},
+		{`after(1)->return("abc")->return("def")`, []string{"def", "abc", "abc"}},
 	}
 	for _, tt := range tests {
 		ter, err := newTerms("test", tt.desc)
@@ -55,6 +82,14 @@ func TestTermsTypes(t *testing.T) {
 		{`return(true)`, true},
 		{`return(1)`, 1},
 		{`return()`, struct{}{}},
+		{`return(error("disk full"))`, errors.New("disk full")},
+		{`error("disk full")`, errors.New("disk full")},
+		{`error`, errors.New("failpoint error: test")},
+		{`sleep(1)&return("timeout")`, "timeout"},
+		{`return(1)&print`, 1},
+		{`return(1)&return(2)`, 2},
+		{`print&off`, nil},
+		{`print&error("disk full")`, errors.New("disk full")},
 	}
 	for _, tt := range tests {
 		ter, err := newTerms("test", tt.desc)
@@ -67,6 +102,310 @@ func TestTermsTypes(t *testing.T) {
 	}
 }
 
+func TestTermsErrorSentinels(t *testing.T) {
+	tests := []struct {
+		desc string
+		werr error
+	}{
+		{`error(io.EOF)`, io.EOF},
+		{`return(io.ErrUnexpectedEOF)`, io.ErrUnexpectedEOF},
+		{`error(os.ErrNotExist)`, os.ErrNotExist},
+		{`2*error(context.DeadlineExceeded)`, context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		ter, err := newTerms("test", tt.desc)
+		require.NoError(t, err)
+		v := ter.eval()
+		verr, ok := v.(error)
+		require.Truef(t, ok, "got %v of type %T, expected an error", v, v)
+		require.ErrorIsf(t, verr, tt.werr, "got %v, expected %v", verr, tt.werr)
+	}
+}
+
+func TestTermsBadError(t *testing.T) {
+	for _, desc := range []string{`error(1)`, `error(true)`, `return(io.Nope)`, `return(error("x")`, `error(error(1))`} {
+		_, err := newTerms("test", desc)
+		require.ErrorIsf(t, err, ErrBadParse, "expected %q to fail parsing", desc)
+	}
+}
+
+func TestTermsBadPeriod(t *testing.T) {
+	for _, desc := range []string{`1/0return`, `0/3return`, `4/3return`, `1/return`, `1/1.5return`, `1/3`} {
+		_, err := newTerms("test", desc)
+		require.ErrorIsf(t, err, ErrBadParse, "expected %q to fail parsing", desc)
+	}
+}
+
+func TestTermsCompositePanic(t *testing.T) {
+	ter, err := newTerms("test", `print&panic("boom")&return(1)`)
+	require.NoError(t, err)
+	assert.PanicsWithValue(t, "failpoint panic: boom", func() { ter.eval() })
+}
+
+func TestTermsSkipStatus(t *testing.T) {
+	ter, err := newTerms("test", `3+return("abc")->1+return("def")`)
+	require.NoError(t, err)
+
+	desc, _ := ter.status()
+	assert.Equal(t, `3+return("abc")->1+return("def") [3+return("abc"): skip 3 more; 1+return("def"): skip 1 more]`, desc)
+
+	assert.Nil(t, ter.eval())
+	desc, _ = ter.status()
+	assert.Equal(t, `3+return("abc")->1+return("def") [3+return("abc"): skip 2 more]`, desc)
+
+	assert.Equal(t, "def", ter.eval())
+	assert.Equal(t, "def", ter.eval())
+	assert.Equal(t, "abc", ter.eval())
+	desc, count := ter.status()
+	assert.Equal(t, `3+return("abc")->1+return("def")`, desc)
+	assert.Equal(t, 3, count)
+
+	// the skipped evaluations are counted whatever the other mods of the term
+	ter, err = newTerms("test", `2*3+return("abc")`)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, ter.eval())
+	}
+	desc, count = ter.status()
+	assert.Equal(t, `2*3+return("abc")`, desc)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, "abc", ter.eval())
+}
+
+func TestTermsTimeWindow(t *testing.T) {
+	ter, err := newTerms("test", `after("100ms")for("200ms")return("abc")`)
+	require.NoError(t, err)
+
+	assert.Nil(t, ter.eval())
+	desc, _ := ter.status()
+	assert.Regexp(t, `^after\("100ms"\)for\("200ms"\)return\("abc"\) \[.*: armed in \d+ms, expires in \d+ms\]$`, desc)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "abc", ter.eval())
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, ter.eval())
+	desc, _ = ter.status()
+	assert.Equal(t, `after("100ms")for("200ms")return("abc") [after("100ms")for("200ms")return("abc"): expired]`, desc)
+}
+
+func TestTermsBadTimeWindow(t *testing.T) {
+	for _, desc := range []string{`for(1)return`, `after("1x")return`, `for("-1s")return`, `after(true)return`, `for("1s")`} {
+		_, err := newTerms("test", desc)
+		require.ErrorIsf(t, err, ErrBadParse, "expected %q to fail parsing", desc)
+	}
+}
+
+func TestTermsSeed(t *testing.T) {
+	defer SetSeed(Seed())
+
+	evalN := func(name string) (ret []interface{}) {
+		ter, err := newTerms(name, `50.0%return(1)`)
+		require.NoError(t, err)
+		for i := 0; i < 64; i++ {
+			ret = append(ret, ter.eval())
+		}
+		return ret
+	}
+
+	SetSeed(42)
+	first := evalN("test")
+	assert.Equalf(t, first, evalN("test"), "same seed and failpoint should replay the same stream")
+	assert.NotEqualf(t, first, evalN("other"), "different failpoints should have different streams")
+
+	SetSeed(43)
+	assert.NotEqualf(t, first, evalN("test"), "different seeds should have different streams")
+}
+
+func TestTermsLogSeed(t *testing.T) {
+	defer SetSeed(Seed())
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer SetLogger(nil)
+
+	SetSeed(42)
+	for _, desc := range []string{`return(1)`, `sleep(10)`, `yield(3)`} {
+		_, err := newTerms("test", desc)
+		require.NoError(t, err)
+	}
+	assert.Emptyf(t, buf.String(), "deterministic terms should not log the seed")
+
+	for _, desc := range []string{`10%return(1)`, `sleep(exp(10))`, `1*off->yield("10us")`} {
+		buf.Reset()
+		SetSeed(42)
+		_, err := newTerms("test", desc)
+		require.NoError(t, err)
+		assert.Containsf(t, buf.String(), `msg="failpoint: using seed, set GOFAIL_SEED to replay" seed=42`, "%s should log the seed", desc)
+	}
+
+	buf.Reset()
+	_, err := newTerms("other", `50%return(1)`)
+	require.NoError(t, err)
+	assert.Emptyf(t, buf.String(), "the seed should be logged once")
+}
+
+func TestTermsExit(t *testing.T) {
+	if desc := os.Getenv("GOFAIL_TEST_TERMS"); len(desc) > 0 {
+		// running in the child process
+		ter, err := newTerms("test", desc)
+		require.NoError(t, err)
+		defer fmt.Println("deferred function ran")
+		ter.eval()
+		return
+	}
+
+	tests := []struct {
+		desc  string
+		wcode int
+	}{
+		{`exit`, 1},
+		{`exit(3)`, 3},
+		// -1 reports the process was terminated by a signal
+		{`kill`, -1},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestTermsExit$")
+		cmd.Env = append(os.Environ(), "GOFAIL_TEST_TERMS="+tt.desc)
+		out, err := cmd.CombinedOutput()
+
+		var exitErr *exec.ExitError
+		require.ErrorAsf(t, err, &exitErr, "%s: expected the process to fail, output: %s", tt.desc, out)
+		assert.Equalf(t, tt.wcode, exitErr.ExitCode(), "%s: unexpected exit code", tt.desc)
+		assert.NotContainsf(t, string(out), "deferred function ran", "%s: deferred functions should not run", tt.desc)
+	}
+}
+
+func TestTermsForensics(t *testing.T) {
+	dir := t.TempDir()
+	stackPath := filepath.Join(dir, "stack.txt")
+	heapPath := filepath.Join(dir, "heap.pprof")
+	goroutinePath := filepath.Join(dir, "goroutine.pprof")
+
+	for _, desc := range []string{
+		fmt.Sprintf("stack(%q)", stackPath),
+		fmt.Sprintf("stack(%q)", stackPath),
+		fmt.Sprintf("heapprofile(%q)", heapPath),
+		fmt.Sprintf("goroutineprofile(%q)", goroutinePath),
+	} {
+		ter, err := newTerms("test", desc)
+		require.NoError(t, err)
+		assert.Nil(t, ter.eval())
+	}
+
+	stack, err := os.ReadFile(stackPath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(stack), "failpoint stack: test (execution 1)"))
+	assert.Contains(t, string(stack), "TestTermsForensics")
+
+	for _, path := range []string{heapPath, goroutinePath} {
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Positivef(t, fi.Size(), "%s should not be empty", path)
+	}
+}
+
+func TestTermsResources(t *testing.T) {
+	ter, err := newTerms("test", `1*alloc("2MB")->1*alloc(1024)->fds(3)`)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, ter.eval())
+	}
+	require.Len(t, ter.held, 5)
+	assert.Len(t, ter.held[0], 2<<20)
+	assert.Len(t, ter.held[1], 1024)
+	f, ok := ter.held[2].(*os.File)
+	require.True(t, ok)
+
+	ter.stop()
+	assert.Empty(t, ter.held)
+	require.ErrorIsf(t, f.Close(), os.ErrClosed, "descriptors should be closed once stopped")
+
+	// resources acquired after stopping are released right away
+	assert.Nil(t, ter.eval())
+	assert.Empty(t, ter.held)
+
+	// sizes which cannot be allocated are ignored
+	for _, desc := range []string{`alloc(-1)`, `alloc("99999999999GB")`, `alloc("8388607GB")`} {
+		ter, err = newTerms("test", desc)
+		require.NoError(t, err)
+		assert.NotPanicsf(t, func() { ter.eval() }, "%s should not panic", desc)
+		assert.Emptyf(t, ter.held, "%s should not hold memory", desc)
+	}
+}
+
+func TestTermsBurn(t *testing.T) {
+	ter, err := newTerms("test", `burn("50ms")`)
+	require.NoError(t, err)
+	start := time.Now()
+	assert.Nil(t, ter.eval())
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	ter, err = newTerms("test", `burn("10m")`)
+	require.NoError(t, err)
+	time.AfterFunc(10*time.Millisecond, ter.stop)
+	start = time.Now()
+	assert.Nil(t, ter.eval())
+	assert.Lessf(t, time.Since(start), time.Minute, "burn should stop once the terms are stopped")
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		s     string
+		wsize int
+		werr  bool
+	}{
+		{"512", 512, false},
+		{"512B", 512, false},
+		{"4KB", 4 << 10, false},
+		{"4KiB", 4 << 10, false},
+		{"512MB", 512 << 20, false},
+		{"1GiB", 1 << 30, false},
+		{"1 GB", 1 << 30, false},
+		{"MB", 0, true},
+		{"-1MB", 0, true},
+		{"1TB", 0, true},
+		{"99999999999GB", 0, true},
+		{"9223372036854775807KB", 0, true},
+		{"99999999999999999999", 0, true},
+	}
+	for _, tt := range tests {
+		size, err := parseSize(tt.s)
+		if tt.werr {
+			require.Errorf(t, err, "expected %q to fail", tt.s)
+			continue
+		}
+		require.NoErrorf(t, err, "failed to parse %q", tt.s)
+		assert.Equalf(t, tt.wsize, size, "unexpected size for %q", tt.s)
+	}
+}
+
+func TestTermsYield(t *testing.T) {
+	for _, desc := range []string{`yield`, `yield(10)`, `yield("10us")`} {
+		ter, err := newTerms("test", desc)
+		require.NoError(t, err)
+		assert.Nilf(t, ter.eval(), "%s should not trigger the failpoint", desc)
+	}
+
+	// yielding for a while returns once the terms are stopped or ctx is done;
+	// the seed makes yield("10h") draw a sleep of hours
+	defer SetSeed(Seed())
+	SetSeed(1)
+	ter, err := newTerms("test", `yield("10h")`)
+	require.NoError(t, err)
+	time.AfterFunc(10*time.Millisecond, ter.stop)
+	start := time.Now()
+	assert.Nil(t, ter.eval())
+	assert.Lessf(t, time.Since(start), time.Second, "yield should stop once the terms are stopped")
+
+	ter, err = newTerms("test", `yield("10h")`)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	assert.Nil(t, ter.evalCtx(ctx))
+	assert.Lessf(t, time.Since(start), time.Second, "yield should return once ctx is done")
+}
+
 func TestTermsCounter(t *testing.T) {
 	tests := []struct {
 		failpointTerm    string
@@ -0,0 +1,274 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var (
+	// values holds the values registered by RegisterValue.
+	values = make(map[string]any)
+	// valuesMu protects values
+	valuesMu sync.RWMutex
+)
+
+// RegisterValue registers a Go value under a name, so terms can evaluate to
+// it with "@name", e.g. "return(@name)" or "error(@name)". This allows values
+// that cannot be written as literals, such as custom error types. Named values
+// are looked up whenever the action is executed: registering a name again
+// replaces its value for the enabled terms too, and an action naming a value
+// which is not registered is skipped. It panics if the name is not a valid
+// identifier or the value is nil.
+func RegisterValue(name string, v any) {
+	if !isIdent(name) {
+		panic(fmt.Sprintf("failpoint value name %q is invalid.", name))
+	}
+	if v == nil {
+		panic(fmt.Sprintf("failpoint value %s is nil.", name))
+	}
+
+	valuesMu.Lock()
+	defer valuesMu.Unlock()
+	values[name] = v
+}
+
+func lookupValue(name string) (any, bool) {
+	valuesMu.RLock()
+	defer valuesMu.RUnlock()
+	v, ok := values[name]
+	return v, ok
+}
+
+// valueRef is the name of a value registered by RegisterValue.
+type valueRef string
+
+func (r valueRef) String() string { return "@" + string(r) }
+
+// nilValue is the value of the nil literal. It is converted to the zero
+// value of a slice, map, pointer or interface type.
+type nilValue struct{}
+
+var (
+	nilValueType = reflect.TypeOf(nilValue{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// typeNames maps the type names known to terms to their types. They can be
+// used in composite literals, and a failpoint declared with such a type gets
+// its values converted to it.
+var typeNames = map[string]reflect.Type{
+	"bool":          reflect.TypeOf(false),
+	"string":        reflect.TypeOf(""),
+	"int":           reflect.TypeOf(int(0)),
+	"int8":          reflect.TypeOf(int8(0)),
+	"int16":         reflect.TypeOf(int16(0)),
+	"int32":         reflect.TypeOf(int32(0)),
+	"int64":         reflect.TypeOf(int64(0)),
+	"uint":          reflect.TypeOf(uint(0)),
+	"uint8":         reflect.TypeOf(uint8(0)),
+	"uint16":        reflect.TypeOf(uint16(0)),
+	"uint32":        reflect.TypeOf(uint32(0)),
+	"uint64":        reflect.TypeOf(uint64(0)),
+	"byte":          reflect.TypeOf(byte(0)),
+	"rune":          reflect.TypeOf(rune(0)),
+	"float32":       reflect.TypeOf(float32(0)),
+	"float64":       reflect.TypeOf(float64(0)),
+	"time.Duration": durationType,
+	"error":         errorType,
+	"any":           reflect.TypeOf((*any)(nil)).Elem(),
+}
+
+// lookupType returns the type described by a Go type expression such as
+// "int64" or "map[string][]int", or nil if it is not made of known types.
+func lookupType(s string) reflect.Type {
+	toks, err := lex(s)
+	if err != nil {
+		return nil
+	}
+	p := &parser{input: s, toks: toks}
+	typ, _, err := p.parseType()
+	if err != nil || p.peek().kind != tokEOF {
+		return nil
+	}
+	return typ
+}
+
+// convertValue converts a term value to the given type, following the Go
+// conversion rules for numbers as long as no precision is lost. Strings convert
+// to durations by parsing them, and slices and maps are converted element by
+// element. It returns false if the value cannot be converted.
+func convertValue(v interface{}, typ reflect.Type) (interface{}, bool) {
+	if v == nil {
+		return v, false
+	}
+	rv, ok := convertReflect(reflect.ValueOf(v), typ)
+	if !ok || (rv.Kind() == reflect.Interface && rv.IsNil()) {
+		// a nil interface would leave the failpoint untriggered
+		return v, false
+	}
+	return rv.Interface(), true
+}
+
+func convertReflect(rv reflect.Value, typ reflect.Type) (reflect.Value, bool) {
+	if rv.Type() == typ {
+		return rv, true
+	}
+	if rv.Kind() == reflect.Interface && !rv.IsNil() {
+		// elements of []any and map[string]any
+		return convertReflect(rv.Elem(), typ)
+	}
+	if rv.Type() == nilValueType || rv.Kind() == reflect.Interface {
+		switch typ.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Pointer, reflect.Interface:
+			return reflect.Zero(typ), true
+		}
+		return rv, false
+	}
+	out := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Interface:
+		if !rv.Type().Implements(typ) {
+			return rv, false
+		}
+		out.Set(rv)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = rv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if rv.Uint() > math.MaxInt64 {
+				return rv, false
+			}
+			n = int64(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			f := rv.Float()
+			if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return rv, false
+			}
+			n = int64(f)
+		case reflect.String:
+			if typ != durationType {
+				return rv, false
+			}
+			d, err := time.ParseDuration(rv.String())
+			if err != nil {
+				return rv, false
+			}
+			n = int64(d)
+		default:
+			return rv, false
+		}
+		if out.OverflowInt(n) {
+			return rv, false
+		}
+		out.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if rv.Int() < 0 {
+				return rv, false
+			}
+			n = uint64(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = rv.Uint()
+		case reflect.Float32, reflect.Float64:
+			f := rv.Float()
+			if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+				return rv, false
+			}
+			n = uint64(f)
+		default:
+			return rv, false
+		}
+		if out.OverflowUint(n) {
+			return rv, false
+		}
+		out.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f = float64(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f = float64(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			f = rv.Float()
+		default:
+			return rv, false
+		}
+		if out.OverflowFloat(f) {
+			return rv, false
+		}
+		out.SetFloat(f)
+	case reflect.Bool:
+		if rv.Kind() != reflect.Bool {
+			return rv, false
+		}
+		out.SetBool(rv.Bool())
+	case reflect.String:
+		if rv.Kind() != reflect.String && !isBytes(rv.Type()) {
+			return rv, false
+		}
+		out.Set(rv.Convert(typ))
+	case reflect.Slice:
+		if rv.Kind() == reflect.String && isBytes(typ) {
+			out.Set(rv.Convert(typ))
+			break
+		}
+		if rv.Kind() != reflect.Slice {
+			return rv, false
+		}
+		out.Set(reflect.MakeSlice(typ, rv.Len(), rv.Len()))
+		for i := 0; i < rv.Len(); i++ {
+			e, ok := convertReflect(rv.Index(i), typ.Elem())
+			if !ok {
+				return rv, false
+			}
+			out.Index(i).Set(e)
+		}
+	case reflect.Map:
+		if rv.Kind() != reflect.Map {
+			return rv, false
+		}
+		out.Set(reflect.MakeMapWithSize(typ, rv.Len()))
+		for it := rv.MapRange(); it.Next(); {
+			k, ok := convertReflect(it.Key(), typ.Key())
+			if !ok {
+				return rv, false
+			}
+			e, ok := convertReflect(it.Value(), typ.Elem())
+			if !ok {
+				return rv, false
+			}
+			out.SetMapIndex(k, e)
+		}
+	default:
+		return rv, false
+	}
+	return out, true
+}
+
+// isBytes reports whether typ is a slice of bytes.
+func isBytes(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8
+}
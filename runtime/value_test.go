@@ -0,0 +1,167 @@
+// Copyright 2026 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupType(t *testing.T) {
+	tests := []struct {
+		varType string
+		typ     reflect.Type
+	}{
+		{"int64", reflect.TypeOf(int64(0))},
+		{"time.Duration", reflect.TypeOf(time.Duration(0))},
+		{"[]byte", reflect.TypeOf([]byte(nil))},
+		{"map[string][]int", reflect.TypeOf(map[string][]int(nil))},
+		{"error", reflect.TypeOf((*error)(nil)).Elem()},
+		{"struct{}", reflect.TypeOf(struct{}{})},
+		{"interface{}", reflect.TypeOf((*any)(nil)).Elem()},
+		{"*int", nil},
+		{"MyType", nil},
+		{"[]int garbage", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		assert.Equalf(t, tt.typ, lookupType(tt.varType), "unexpected type for %q", tt.varType)
+	}
+}
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		v       interface{}
+		varType string
+		want    interface{}
+	}{
+		{1, "int64", int64(1)},
+		{-1, "int8", int8(-1)},
+		{1, "uint", uint(1)},
+		{1, "float32", float32(1)},
+		{2.0, "int", 2},
+		{0.5, "float32", float32(0.5)},
+		{"10ms", "time.Duration", 10 * time.Millisecond},
+		{10, "time.Duration", time.Duration(10)},
+		{time.Second, "int64", int64(time.Second)},
+		{"abc", "[]byte", []byte("abc")},
+		{[]byte("abc"), "string", "abc"},
+		{[]int{1, 2}, "[]int64", []int64{1, 2}},
+		{map[string]int{"a": 1}, "map[string]float64", map[string]float64{"a": 1}},
+		{[]any{1, 2}, "[]uint16", []uint16{1, 2}},
+		{nilValue{}, "[]string", []string(nil)},
+		{nilValue{}, "map[string]int", map[string]int(nil)},
+		{io.EOF, "error", io.EOF},
+		{"abc", "any", "abc"},
+	}
+	for _, tt := range tests {
+		got, ok := convertValue(tt.v, lookupType(tt.varType))
+		require.Truef(t, ok, "expected %#v to convert to %s", tt.v, tt.varType)
+		assert.Equalf(t, tt.want, got, "unexpected conversion of %#v to %s", tt.v, tt.varType)
+	}
+}
+
+func TestConvertValueInvalid(t *testing.T) {
+	tests := []struct {
+		v       interface{}
+		varType string
+	}{
+		{1.5, "int"},
+		{-1, "uint"},
+		{256, "uint8"},
+		{1e40, "float32"},
+		{1, "string"},
+		{"1", "int"},
+		{"1x", "time.Duration"},
+		{true, "int"},
+		{"abc", "error"},
+		{nilValue{}, "int"},
+		{nilValue{}, "error"},
+		{[]int{-1}, "[]uint"},
+		{map[string]int{"a": 1}, "map[int]int"},
+	}
+	for _, tt := range tests {
+		got, ok := convertValue(tt.v, lookupType(tt.varType))
+		assert.Falsef(t, ok, "expected %#v not to convert to %s", tt.v, tt.varType)
+		assert.Equalf(t, tt.v, got, "expected %#v to be left unchanged", tt.v)
+	}
+}
+
+type corruptEntry struct {
+	index int
+}
+
+func TestRegisterValue(t *testing.T) {
+	defer clearValues()
+
+	pathErr := &os.PathError{Op: "open", Path: "/data/wal", Err: os.ErrPermission}
+	RegisterValue("corruptEntry", corruptEntry{index: 3})
+	RegisterValue("pathErr", pathErr)
+
+	ter, err := newTerms("test", `1*return(@corruptEntry)->1*error( @pathErr )->return(@missing)`)
+	require.NoError(t, err)
+	assert.Equal(t, `1*return(@corruptEntry)->1*error(@pathErr)->return(@missing)`, ter.String())
+
+	assert.Equal(t, corruptEntry{index: 3}, ter.eval())
+
+	v := ter.eval()
+	var perr *os.PathError
+	require.ErrorAs(t, v.(error), &perr)
+	assert.Same(t, pathErr, perr)
+	assert.ErrorIs(t, v.(error), os.ErrPermission)
+
+	assert.Nilf(t, ter.eval(), "unregistered values should be skipped")
+	RegisterValue("missing", "late")
+	assert.Equalf(t, "late", ter.eval(), "values should be resolved on execution")
+	RegisterValue("missing", "replaced")
+	assert.Equalf(t, "replaced", ter.eval(), "registering again should replace the value")
+
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer SetLogger(nil)
+	RegisterValue("notErr", corruptEntry{index: 3})
+	ter, err = newTerms("test", `error(@notErr)`)
+	require.NoError(t, err)
+	assert.Nilf(t, ter.eval(), "error should not trigger with a value which is not an error")
+	assert.Contains(t, buf.String(), `level=WARN msg="failpoint: ignoring error" failpoint=test`)
+}
+
+func TestRegisterValueInvalid(t *testing.T) {
+	defer clearValues()
+
+	for _, name := range []string{"", "1st", "with space", "io.EOF"} {
+		assert.Panicsf(t, func() { RegisterValue(name, 1) }, "expected registering %q to panic", name)
+	}
+	assert.Panics(t, func() { RegisterValue("nil", nil) })
+
+	for _, desc := range []string{`return(@)`, `return(@1)`, `return(@"a")`, `return([]any{@a})`, `log(@a)`} {
+		_, err := newTerms("test", desc)
+		assert.ErrorIsf(t, err, ErrBadParse, "expected %q to fail parsing", desc)
+	}
+}
+
+func clearValues() {
+	valuesMu.Lock()
+	defer valuesMu.Unlock()
+	values = make(map[string]any)
+}